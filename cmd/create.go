@@ -2,12 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/okteto/cnd/pkg/linguist"
-	yaml "gopkg.in/yaml.v2"
+	"github.com/okteto/cnd/pkg/model"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -46,13 +45,7 @@ func executeCreate(devPath string) error {
 
 	dev := linguist.GetDevConfig(languagesDiscovered[0])
 	dev.Swap.Deployment.Name = path.Base(root)
-	marshalled, err := yaml.Marshal(dev)
-	if err != nil {
-		log.Error(err)
-		return fmt.Errorf("Failed to generate your cnd manifest")
-	}
-
-	if err := ioutil.WriteFile(devPath, marshalled, 0600); err != nil {
+	if err := model.WriteDev(dev, devPath); err != nil {
 		log.Error(err)
 		return fmt.Errorf("Failed to generate your cnd manifest")
 	}
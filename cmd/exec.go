@@ -71,21 +71,16 @@ func executeExec(args []string) error {
 }
 
 func findDevEnvironment(mustBeRunning bool) (string, string, string, error) {
-	services := storage.All()
-	candidates := []storage.Service{}
-	deploymentFullName := ""
+	candidates := []storage.ServiceEntry{}
 	folder, _ := os.Getwd()
 
-	for name, svc := range services {
+	for _, svc := range storage.List() {
 		if strings.HasPrefix(folder, svc.Folder) {
 			if mustBeRunning && svc.Syncthing == "" {
 				continue
 			}
 
 			candidates = append(candidates, svc)
-			if deploymentFullName == "" {
-				deploymentFullName = name
-			}
 		}
 	}
 
@@ -94,13 +89,8 @@ func findDevEnvironment(mustBeRunning bool) (string, string, string, error) {
 	}
 
 	if len(candidates) > 1 {
-		fmt.Printf("warning: there are %d cloud native development environments active in your current folder, using '%s'\n", len(candidates), deploymentFullName)
+		fmt.Printf("warning: there are %d cloud native development environments active in your current folder, using '%s'\n", len(candidates), candidates[0].Name)
 	}
 
-	parts := strings.SplitN(deploymentFullName, "/", 3)
-	namespace := parts[0]
-	deploymentName := parts[1]
-	devContainer := parts[2]
-
-	return namespace, deploymentName, devContainer, nil
+	return candidates[0].Namespace, candidates[0].Deployment, candidates[0].Container, nil
 }
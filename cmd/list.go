@@ -27,6 +27,7 @@ type listOutput struct {
 type listOutputEnvironment struct {
 	Name       string   `yaml:"name,omitempty"`
 	Source     string   `yaml:"source,omitempty"`
+	Status     string   `yaml:"status,omitempty"`
 	Completion string   `yaml:"completion,omitempty"`
 	Errors     []string `yaml:"errors,omitempty"`
 }
@@ -84,19 +85,18 @@ func List() *cobra.Command {
 }
 
 func list(yamlOutput bool) error {
-	services := storage.All()
-
 	output := listOutput{
 		Environments: []listOutputEnvironment{},
 	}
 
-	for name, svc := range services {
+	for _, svc := range storage.List() {
 		env := listOutputEnvironment{
-			Name:   name,
+			Name:   svc.Name,
 			Source: svc.Folder,
+			Status: svc.Status,
 		}
 
-		completion, err := getStatus(svc)
+		completion, err := getStatus(svc.Service)
 		if err == nil {
 			env.Completion = fmt.Sprintf("%2.f%%", completion)
 		} else {
@@ -104,7 +104,7 @@ func list(yamlOutput bool) error {
 			env.Completion = "?"
 		}
 
-		apiErrors, err := getErrors(svc)
+		apiErrors, err := getErrors(svc.Service)
 		if err != nil {
 			log.Infof("Failed to get errors of %s: %s", svc.Folder, err)
 			continue
@@ -122,7 +122,7 @@ func list(yamlOutput bool) error {
 }
 
 func getStatus(s storage.Service) (float64, error) {
-	urlPath := path.Join(s.Syncthing, "rest", "events")
+	urlPath := path.Join(s.ResolvedHost(), "rest", "events")
 	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s", urlPath), nil)
 	if err != nil {
 		return 100, err
@@ -163,7 +163,7 @@ func getStatus(s storage.Service) (float64, error) {
 }
 
 func getErrors(s storage.Service) ([]string, error) {
-	urlPath := path.Join(s.Syncthing, "rest", "system", "error")
+	urlPath := path.Join(s.ResolvedHost(), "rest", "system", "error")
 	log.Debugf("getting errors via %s", urlPath)
 	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s", urlPath), nil)
 	if err != nil {
@@ -221,6 +221,9 @@ func printDirectly(o *listOutput) error {
 		var buff bytes.Buffer
 		buff.WriteString(fmt.Sprintf("\t%s\n", e.Name))
 		buff.WriteString(fmt.Sprintf("\t\t%-15s%s\n", "source:", e.Source))
+		if e.Status != "" {
+			buff.WriteString(fmt.Sprintf("\t\t%-15s%s\n", "status:", e.Status))
+		}
 		buff.WriteString(fmt.Sprintf("\t\t%-15s%s\n", "completion:", e.Completion))
 
 		for _, er := range e.Errors {
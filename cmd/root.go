@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"sync"
 
 	"github.com/okteto/cnd/pkg/analytics"
 	"github.com/okteto/cnd/pkg/k8/client"
+	"github.com/okteto/cnd/pkg/model"
+	"github.com/okteto/cnd/pkg/storage"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	runtime "k8s.io/apimachinery/pkg/util/runtime"
@@ -50,6 +53,7 @@ func init() {
 		Run(),
 		Create(),
 		Analytics(),
+		Validate(),
 	)
 
 	// override client-go error handlers to downgrade the "logging before flag.Parse" error
@@ -71,6 +75,8 @@ func Execute() {
 }
 
 func getKubernetesClient(namespace string) (string, *kubernetes.Clientset, *rest.Config, error) {
+	// the service entries of different clusters are kept apart by their context
+	storage.SetContext(client.GetCurrentContext())
 	return client.Get(namespace)
 }
 
@@ -78,6 +84,18 @@ func addDevPathFlag(cmd *cobra.Command, devPath *string) {
 	cmd.Flags().StringVarP(devPath, "file", "f", "cnd.yml", "path to the cnd manifest file")
 }
 
+func printDeprecations(dev *model.Dev) {
+	for _, d := range dev.Deprecations() {
+		fmt.Printf("warning: %s\n", d)
+	}
+}
+
+func printConsistency(dev *model.Dev) {
+	for _, w := range dev.Consistency() {
+		fmt.Printf("warning: %s\n", w)
+	}
+}
+
 func exit() {
 	analytics.Wait()
 	os.Exit(1)
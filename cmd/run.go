@@ -40,11 +40,18 @@ func executeRun(devPath string, args []string) error {
 		return err
 	}
 
-	if val, ok := dev.Scripts[args[0]]; ok {
-		return executeExec(parseArguments(val, args))
+	printDeprecations(dev)
+
+	if !dev.HasScript(args[0]) {
+		return fmt.Errorf("%s is not defined in %s", args[0], devPath)
+	}
+
+	val, err := dev.RenderScript(args[0])
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("%s is not defined in %s", args[0], devPath)
+	return executeExec(parseArguments(val, args))
 
 }
 
@@ -9,6 +9,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/okteto/cnd/pkg/k8/cp"
 	"github.com/okteto/cnd/pkg/k8/deployments"
 	"github.com/okteto/cnd/pkg/k8/forward"
 	"github.com/okteto/cnd/pkg/storage"
@@ -47,21 +48,52 @@ func executeUp(devPath, namespace string) error {
 		return fmt.Errorf("there is already an entry for %s. Are you running 'cnd up' somewhere else?", deployments.GetFullName(namespace, deploymentName))
 	}
 
-	namespace, client, restConfig, err := getKubernetesClient(namespace)
+	dev, err := model.ReadDev(devPath)
 	if err != nil {
 		return err
 	}
 
-	dev, err := model.ReadDev(devPath)
+	if namespace == "" {
+		namespace = dev.Swap.Deployment.Namespace
+	}
+
+	namespace, client, restConfig, err := getKubernetesClient(namespace)
 	if err != nil {
 		return err
 	}
 
+	printDeprecations(dev)
+	printConsistency(dev)
+
+	if len(dev.EnabledMounts()) > 1 {
+		return fmt.Errorf("synchronizing more than one mount is not supported yet")
+	}
+
+	if dev.IsSynched() && dev.MainMount().IsRemote() {
+		return fmt.Errorf("synchronizing a remote source is not supported yet")
+	}
+
+	if len(dev.Swap.Selector) > 0 {
+		names, err := deployments.ListNames(namespace, dev.Swap.Selector, client)
+		if err != nil {
+			return err
+		}
+
+		if err := dev.ResolveSelector(names); err != nil {
+			return err
+		}
+	}
+
 	d, err := deployments.Get(namespace, dev.Swap.Deployment.Name, client)
 	if err != nil {
 		return err
 	}
 
+	originalCommand, originalArgs, err := deployments.GetOriginalCommand(dev, d)
+	if err != nil {
+		return err
+	}
+
 	if err := deployments.DevModeOn(dev, d, client); err != nil {
 		return err
 	}
@@ -71,7 +103,16 @@ func executeUp(devPath, namespace string) error {
 		return err
 	}
 
-	if err := deployments.InitVolumeWithTarball(client, restConfig, namespace, pod.Name, dev.Mount.Source); err != nil {
+	if !dev.IsSynched() {
+		if err := storage.InsertWithOriginal(namespace, dev, "", originalCommand, originalArgs); err != nil {
+			return err
+		}
+
+		fmt.Printf("Your cloud native development environment is running on %s without file synchronization\n", pod.Name)
+		return nil
+	}
+
+	if err := deployments.InitVolumeWithTarball(client, restConfig, namespace, pod.Name, dev.MainMount().Source, dev.Sync.Owner()); err != nil {
 		return err
 	}
 
@@ -82,7 +123,7 @@ func executeUp(devPath, namespace string) error {
 
 	fullname := deployments.GetFullName(namespace, d.Name)
 
-	pf, err := forward.NewCNDPortForward(dev.Mount.Source, sy.RemoteAddress, fullname)
+	pf, err := forward.NewCNDPortForward(dev.MainMount().Source, sy.RemoteAddress, fullname)
 	if err != nil {
 		return err
 	}
@@ -91,7 +132,11 @@ func executeUp(devPath, namespace string) error {
 		return err
 	}
 
-	err = storage.Insert(namespace, dev, sy.GUIAddress)
+	done := make(chan struct{})
+	defer close(done)
+	go cp.KeepOwner(client, restConfig, pod, dev.Sync, done)
+
+	err = storage.InsertWithOriginal(namespace, dev, sy.GUIAddress, originalCommand, originalArgs)
 	if err != nil {
 		if err == storage.ErrAlreadyRunning {
 			return fmt.Errorf("there is already an entry for %s. Are you running 'cnd up' somewhere else?", fullname)
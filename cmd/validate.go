@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/okteto/cnd/pkg/model"
+	"github.com/spf13/cobra"
+)
+
+// Validate checks a cnd manifest without activating the cloud native environment
+func Validate() *cobra.Command {
+	var devPath string
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate your cnd.yml file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return executeValidate(devPath, strict)
+		},
+	}
+
+	addDevPathFlag(cmd, &devPath)
+	cmd.Flags().BoolVar(&strict, "strict", false, "reject images without a pinned tag or digest")
+	return cmd
+}
+
+func executeValidate(devPath string, strict bool) error {
+	if err := model.Validate(devPath); err != nil {
+		return err
+	}
+
+	if strict {
+		dev, err := model.ReadDev(devPath)
+		if err != nil {
+			return err
+		}
+
+		if err := dev.ValidateStrictImage(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s is valid\n", devPath)
+	return nil
+}
@@ -10,13 +10,26 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
-//Get returns a kubernetes client. If namespace is empty, it will use the default namespace configured.
-func Get(namespace string) (string, *kubernetes.Clientset, *rest.Config, error) {
+// GetCurrentContext returns the kubernetes context of the kubeconfig, or an empty string if it can't be read
+func GetCurrentContext() string {
+	config, err := getClientConfig().RawConfig()
+	if err != nil {
+		return ""
+	}
+
+	return config.CurrentContext
+}
+
+func getClientConfig() clientcmd.ClientConfig {
 	home := os.Getenv("HOME")
-	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		&clientcmd.ClientConfigLoadingRules{ExplicitPath: path.Join(home, ".kube/config")},
 		&clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{Server: ""}})
+}
 
+//Get returns a kubernetes client. If namespace is empty, it will use the default namespace configured.
+func Get(namespace string) (string, *kubernetes.Clientset, *rest.Config, error) {
+	clientConfig := getClientConfig()
 	if namespace == "" {
 		var err error
 		namespace, _, err = clientConfig.Namespace()
@@ -19,8 +19,13 @@ import (
 var tarCommand = []string{"tar", "-xzf", "-", "--strip-components=1", "-C", "/src"}
 var touchCommand = []string{"touch", "/initialized"}
 
-// Copy copies a local folder to the remote volume
-func Copy(c *kubernetes.Clientset, config *rest.Config, namespace string, pod *apiv1.Pod, folder string) error {
+// chownCommand changes the owner of the files extracted in the remote volume, e.g. 1000:1000
+func chownCommand(owner string) []string {
+	return []string{"chown", "-R", owner, "/src"}
+}
+
+// Copy copies a local folder to the remote volume. If owner is not empty, the copied files are owned by it
+func Copy(c *kubernetes.Clientset, config *rest.Config, namespace string, pod *apiv1.Pod, folder, owner string) error {
 	dir := os.TempDir()
 	tarfile := filepath.Join(dir, fmt.Sprintf("tarball-%s.tgz", uuid.NewV4().String()))
 	if err := archiver.Archive([]string{folder}, tarfile); err != nil {
@@ -40,6 +45,13 @@ func Copy(c *kubernetes.Clientset, config *rest.Config, namespace string, pod *a
 		return fmt.Errorf("Failed to send tarball")
 	}
 	log.Info("Tarball sent")
+	if owner != "" {
+		if err := exec.Exec(c, config, pod, model.CNDInitSyncContainerName, false, os.Stdin, os.Stdout, os.Stderr, chownCommand(owner)); err != nil {
+			log.Errorf("failed to change the owner of the files: %s", err.Error())
+			return fmt.Errorf("Failed to change the owner of the synched files to %s", owner)
+		}
+	}
+
 	if err := exec.Exec(c, config, pod, model.CNDInitSyncContainerName, false, os.Stdin, os.Stdout, os.Stderr, touchCommand); err != nil {
 		log.Errorf("failed to sent initialized flag: %s", err.Error())
 		return fmt.Errorf("Failed to send initialized flag")
@@ -0,0 +1,57 @@
+package cp
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/okteto/cnd/pkg/k8/exec"
+	"github.com/okteto/cnd/pkg/model"
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ownerInterval is how often KeepOwner changes the owner of the synched files
+var ownerInterval = 5 * time.Second
+
+// ownerCommand changes the owner of the synched files that don't have it yet, e.g. the files written by syncthing.
+// Only those files are changed, but the whole folder is traversed every time
+func ownerCommand(sync model.Sync) []string {
+	var conditions []string
+	if sync.OwnerUID != nil {
+		conditions = append(conditions, "!", "-user", fmt.Sprintf("%d", *sync.OwnerUID))
+	}
+
+	if sync.OwnerGID != nil {
+		if len(conditions) > 0 {
+			conditions = append(conditions, "-o")
+		}
+		conditions = append(conditions, "!", "-group", fmt.Sprintf("%d", *sync.OwnerGID))
+	}
+
+	command := append([]string{"find", model.CNDSyncMountPath, "("}, conditions...)
+	return append(command, ")", "-exec", "chown", "-h", sync.Owner(), "{}", "+")
+}
+
+// KeepOwner changes the owner of the files synched into the remote volume until stop is closed. syncthing keeps
+// running as its own user, so the files it writes are changed afterwards. It does nothing if the sync has no owner
+func KeepOwner(c *kubernetes.Clientset, config *rest.Config, pod *apiv1.Pod, sync model.Sync, stop <-chan struct{}) {
+	if sync.Owner() == "" {
+		return
+	}
+
+	ticker := time.NewTicker(ownerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := exec.Exec(c, config, pod, model.CNDSyncContainerName, false, os.Stdin, os.Stdout, os.Stderr, ownerCommand(sync)); err != nil {
+				log.Infof("failed to change the owner of the synched files: %s", err)
+			}
+		}
+	}
+}
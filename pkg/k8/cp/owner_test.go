@@ -0,0 +1,43 @@
+package cp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func Test_ownerCommand(t *testing.T) {
+	uid := int64(1000)
+	gid := int64(2000)
+
+	var tests = []struct {
+		name     string
+		sync     model.Sync
+		expected []string
+	}{
+		{
+			name:     "user-and-group",
+			sync:     model.Sync{OwnerUID: &uid, OwnerGID: &gid},
+			expected: []string{"find", "/var/cnd-sync", "(", "!", "-user", "1000", "-o", "!", "-group", "2000", ")", "-exec", "chown", "-h", "1000:2000", "{}", "+"},
+		},
+		{
+			name:     "user",
+			sync:     model.Sync{OwnerUID: &uid},
+			expected: []string{"find", "/var/cnd-sync", "(", "!", "-user", "1000", ")", "-exec", "chown", "-h", "1000", "{}", "+"},
+		},
+		{
+			name:     "group",
+			sync:     model.Sync{OwnerGID: &gid},
+			expected: []string{"find", "/var/cnd-sync", "(", "!", "-group", "2000", ")", "-exec", "chown", "-h", ":2000", "{}", "+"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if command := ownerCommand(tt.sync); !reflect.DeepEqual(command, tt.expected) {
+				t.Errorf("%q != %q", command, tt.expected)
+			}
+		})
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"encoding/json"
-
 	"github.com/okteto/cnd/pkg/k8/cp"
 	"github.com/okteto/cnd/pkg/model"
 	log "github.com/sirupsen/logrus"
@@ -13,6 +11,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -32,16 +31,40 @@ func Get(namespace, deploymentName string, c *kubernetes.Clientset) (*appsv1.Dep
 	return d, err
 }
 
+// ListNames returns the names of the deployments of the namespace matching the labels of the selector
+func ListNames(namespace string, selector map[string]string, c *kubernetes.Clientset) ([]string, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("empty namespace")
+	}
+
+	list, err := c.AppsV1().Deployments(namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(list.Items))
+	for i, d := range list.Items {
+		names[i] = d.Name
+	}
+
+	return names, nil
+}
+
 //DevModeOn activates a cloud native development for a given k8 deployment
 func DevModeOn(dev *model.Dev, d *appsv1.Deployment, c *kubernetes.Clientset) error {
 	dev.Swap.Deployment.Container = getDevContainerOrFirst(dev.Swap.Deployment.Container, d.Spec.Template.Spec.Containers)
+	if err := checkNotSwapped(d, dev); err != nil {
+		return err
+	}
 
-	manifest := getAnnotation(d.GetObjectMeta(), model.CNDDeploymentAnnotation)
-	if manifest != "" {
-		dOrig := &appsv1.Deployment{}
-		if err := json.Unmarshal([]byte(manifest), dOrig); err != nil {
-			return err
-		}
+	dOrig, err := getOriginalDeployment(d)
+	if err != nil {
+		return err
+	}
+
+	if dOrig != nil {
 		dOrig.ResourceVersion = ""
 		d = dOrig
 	}
@@ -59,17 +82,16 @@ func DevModeOn(dev *model.Dev, d *appsv1.Deployment, c *kubernetes.Clientset) er
 
 //DevModeOff deactivates a cloud native development
 func DevModeOff(dev *model.Dev, d *appsv1.Deployment, c *kubernetes.Clientset) error {
-	manifest := getAnnotation(d.GetObjectMeta(), model.CNDDeploymentAnnotation)
-	if manifest == "" {
+	dOrig, err := getOriginalDeployment(d)
+	if err != nil {
+		return err
+	}
+
+	if dOrig == nil {
 		fullname := GetFullName(d.Namespace, d.Name)
 		log.Debugf("%s doesn't have the %s annotation", fullname, model.CNDDeploymentAnnotation)
 		return nil
 	}
-
-	dOrig := &appsv1.Deployment{}
-	if err := json.Unmarshal([]byte(manifest), dOrig); err != nil {
-		return err
-	}
 	dOrig.ResourceVersion = ""
 
 	log.Infof("restoring the production configuration")
@@ -142,8 +164,8 @@ func GetCNDPod(dev *model.Dev, d *appsv1.Deployment, c *kubernetes.Clientset) (*
 	return nil, fmt.Errorf("kubernetes is taking too long to create the cloud native environment. Please check for errors or try again")
 }
 
-// InitVolumeWithTarball initializes the remote volume with a local tarball
-func InitVolumeWithTarball(c *kubernetes.Clientset, config *rest.Config, namespace, podName, folder string) error {
+// InitVolumeWithTarball initializes the remote volume with a local tarball, owned by owner if it's not empty
+func InitVolumeWithTarball(c *kubernetes.Clientset, config *rest.Config, namespace, podName, folder, owner string) error {
 	copied := false
 	tries := 0
 	for tries < 30 && !copied {
@@ -160,7 +182,7 @@ func InitVolumeWithTarball(c *kubernetes.Clientset, config *rest.Config, namespa
 					if copied {
 						time.Sleep(1 * time.Second)
 					} else {
-						if err := cp.Copy(c, config, namespace, pod, folder); err != nil {
+						if err := cp.Copy(c, config, namespace, pod, folder, owner); err != nil {
 							return err
 						}
 						copied = true
@@ -2,11 +2,13 @@ package deployments
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/okteto/cnd/pkg/model"
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
@@ -21,8 +23,7 @@ func translateToDevModeDeployment(d *appsv1.Deployment, dev *model.Dev) error {
 	if err != nil {
 		return err
 	}
-	setAnnotation(d.GetObjectMeta(), model.CNDDeploymentAnnotation, string(manifest))
-	if err := setDevAsAnnotation(d, dev); err != nil {
+	if err := setDevAnnotations(d, dev, manifest); err != nil {
 		return err
 	}
 	setLabel(d.GetObjectMeta(), model.CNDLabel, d.Name)
@@ -31,14 +32,31 @@ func translateToDevModeDeployment(d *appsv1.Deployment, dev *model.Dev) error {
 
 	for i, c := range d.Spec.Template.Spec.Containers {
 		if c.Name == dev.Swap.Deployment.Container || dev.Swap.Deployment.Container == "" {
-			updateCndContainer(&d.Spec.Template.Spec.Containers[i], dev)
+			if err := updateCndContainer(&d.Spec.Template.Spec.Containers[i], dev); err != nil {
+				return err
+			}
 			break
 		}
 	}
 
-	createInitSyncthingContainer(d, dev)
-	createSyncthingContainer(d, dev)
-	createSyncthingVolume(d, dev)
+	for _, swap := range dev.Swap.Deployment.Containers {
+		c := getContainer(d, swap.Name)
+		if c == nil {
+			return fmt.Errorf("container %s doesn't exist in the deployment %s", swap.Name, d.Name)
+		}
+
+		updateSwappedContainer(c, swap, dev)
+	}
+
+	addImagePullSecrets(d, dev.Swap.Deployment.ImagePullSecrets)
+
+	if dev.IsSynched() {
+		createInitSyncthingContainer(d, dev)
+		createSyncthingContainer(d, dev)
+		if err := createSyncthingVolume(d, dev); err != nil {
+			return err
+		}
+	}
 
 	if *(d.Spec.Replicas) != devReplicas {
 		log.Info("cnd only supports running with 1 replica")
@@ -47,7 +65,7 @@ func translateToDevModeDeployment(d *appsv1.Deployment, dev *model.Dev) error {
 	return nil
 }
 
-func updateCndContainer(c *apiv1.Container, dev *model.Dev) {
+func updateCndContainer(c *apiv1.Container, dev *model.Dev) error {
 	if dev.Swap.Deployment.Image != "" {
 		c.Image = dev.Swap.Deployment.Image
 	}
@@ -59,34 +77,170 @@ func updateCndContainer(c *apiv1.Container, dev *model.Dev) {
 		c.Args = dev.Swap.Deployment.Args
 	}
 
-	c.WorkingDir = dev.Mount.Target
+	if !dev.Swap.Deployment.Capabilities.IsEmpty() {
+		if c.SecurityContext == nil {
+			c.SecurityContext = &apiv1.SecurityContext{}
+		}
+
+		c.SecurityContext.Capabilities = &apiv1.Capabilities{
+			Add:  translateCapabilities(dev.Swap.Deployment.Capabilities.Add),
+			Drop: translateCapabilities(dev.Swap.Deployment.Capabilities.Drop),
+		}
+	}
+
+	for _, e := range dev.Environment {
+		setEnvVar(c, e.Name, e.Value)
+	}
+
+	requests, err := translateResourceList(dev.Swap.Deployment.Resources.Requests)
+	if err != nil {
+		return err
+	}
+
+	limits, err := translateResourceList(dev.Swap.Deployment.Resources.Limits)
+	if err != nil {
+		return err
+	}
+
 	c.ReadinessProbe = nil
 	c.LivenessProbe = nil
+	c.Resources = apiv1.ResourceRequirements{
+		Requests: requests,
+		Limits:   limits,
+	}
+
+	if dev.Swap.Deployment.WorkDir != "" {
+		c.WorkingDir = dev.Swap.Deployment.WorkDir
+	}
+
+	mountSyncVolume(c, dev)
+	return nil
+}
+
+// updateSwappedContainer swaps an additional container of the deployment, sharing the synched folders of the main one
+func updateSwappedContainer(c *apiv1.Container, swap model.ContainerSwap, dev *model.Dev) {
+	if swap.Image != "" {
+		c.Image = swap.Image
+	}
+
+	if len(swap.Command) > 0 {
+		c.Command = swap.Command
+	}
+
+	if len(swap.Args) > 0 {
+		c.Args = swap.Args
+	}
+
+	c.ReadinessProbe = nil
+	c.LivenessProbe = nil
+	mountSyncVolume(c, dev)
+}
 
+// mountSyncVolume mounts the sync volume in the targets of the enabled mounts. The volume only holds the files of
+// the main mount, so cnd up rejects the devs with more than one enabled mount
+func mountSyncVolume(c *apiv1.Container, dev *model.Dev) {
+	if !dev.IsSynched() {
+		return
+	}
+
+	c.WorkingDir = dev.ContainerWorkDir(c.Name)
 	if c.VolumeMounts == nil {
 		c.VolumeMounts = []apiv1.VolumeMount{}
 	}
 
-	volumeMount := apiv1.VolumeMount{
-		Name:      model.CNDSyncVolumeName,
-		MountPath: dev.Mount.Target,
+	for _, m := range dev.ContainerMounts(c.Name) {
+		volumeMount := apiv1.VolumeMount{
+			Name:      dev.Names().Volume,
+			MountPath: m.Target,
+			ReadOnly:  m.ReadOnly,
+		}
+
+		c.VolumeMounts = append(
+			c.VolumeMounts,
+			volumeMount,
+		)
 	}
+}
 
-	c.VolumeMounts = append(
-		c.VolumeMounts,
-		volumeMount,
-	)
+// addImagePullSecrets adds the secrets to the pod template, keeping the ones already defined by the deployment
+func addImagePullSecrets(d *appsv1.Deployment, secrets []string) {
+	for _, name := range secrets {
+		found := false
+		for _, s := range d.Spec.Template.Spec.ImagePullSecrets {
+			if s.Name == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			d.Spec.Template.Spec.ImagePullSecrets = append(d.Spec.Template.Spec.ImagePullSecrets, apiv1.LocalObjectReference{Name: name})
+		}
+	}
+}
+
+func translateCapabilities(names []string) []apiv1.Capability {
+	if len(names) == 0 {
+		return nil
+	}
+
+	capabilities := make([]apiv1.Capability, len(names))
+	for i, name := range names {
+		capabilities[i] = apiv1.Capability(name)
+	}
+
+	return capabilities
+}
+
+func getContainer(d *appsv1.Deployment, name string) *apiv1.Container {
+	for i := range d.Spec.Template.Spec.Containers {
+		if d.Spec.Template.Spec.Containers[i].Name == name {
+			return &d.Spec.Template.Spec.Containers[i]
+		}
+	}
+
+	return nil
+}
+
+// setEnvVar sets the value of an environment variable of the container, replacing it if already defined
+func setEnvVar(c *apiv1.Container, name, value string) {
+	for i := range c.Env {
+		if c.Env[i].Name == name {
+			c.Env[i] = apiv1.EnvVar{Name: name, Value: value}
+			return
+		}
+	}
 
-	c.Resources = apiv1.ResourceRequirements{}
+	c.Env = append(c.Env, apiv1.EnvVar{Name: name, Value: value})
+}
+
+// translateResourceList parses the quantities of the resources, which are validated with the dev, but a dev built
+// by the caller may have invalid ones
+func translateResourceList(quantities map[string]string) (apiv1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	list := apiv1.ResourceList{}
+	for name, quantity := range quantities {
+		q, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid quantity for '%s': %s", quantity, name, err)
+		}
+		list[apiv1.ResourceName(name)] = q
+	}
+
+	return list, nil
 }
 
 func createInitSyncthingContainer(d *appsv1.Deployment, dev *model.Dev) {
+	names := dev.Names()
 	initSyncthingContainer := apiv1.Container{
-		Name:  model.CNDInitSyncContainerName,
-		Image: "okteto/init-syncthing:0.3.4",
+		Name:  names.InitContainer,
+		Image: dev.GetInitSyncImage(),
 		VolumeMounts: []apiv1.VolumeMount{
 			apiv1.VolumeMount{
-				Name:      model.CNDSyncVolumeName,
+				Name:      names.Volume,
 				MountPath: "/src",
 			},
 		},
@@ -100,13 +254,14 @@ func createInitSyncthingContainer(d *appsv1.Deployment, dev *model.Dev) {
 }
 
 func createSyncthingContainer(d *appsv1.Deployment, dev *model.Dev) {
+	names := dev.Names()
 	syncthingContainer := apiv1.Container{
-		Name:  model.CNDSyncContainerName,
-		Image: "okteto/syncthing:latest",
+		Name:  names.Container,
+		Image: dev.GetSyncImage(),
 		VolumeMounts: []apiv1.VolumeMount{
 			apiv1.VolumeMount{
-				Name:      model.CNDSyncVolumeName,
-				MountPath: "/var/cnd-sync",
+				Name:      names.Volume,
+				MountPath: names.Mount,
 			},
 		},
 		Ports: []apiv1.ContainerPort{
@@ -119,18 +274,29 @@ func createSyncthingContainer(d *appsv1.Deployment, dev *model.Dev) {
 		},
 	}
 
+	// syncthing keeps its user, the owner of the synched files is changed by cp.Copy and cp.KeepOwner
+
 	d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers, syncthingContainer)
 }
 
-func createSyncthingVolume(d *appsv1.Deployment, dev *model.Dev) {
+func createSyncthingVolume(d *appsv1.Deployment, dev *model.Dev) error {
 	if d.Spec.Template.Spec.Volumes == nil {
 		d.Spec.Template.Spec.Volumes = []apiv1.Volume{}
 	}
 
-	syncVolume := apiv1.Volume{Name: model.CNDSyncVolumeName}
+	syncVolume := apiv1.Volume{Name: dev.Names().Volume}
+	if dev.SyncVolumeSize != "" {
+		size, err := resource.ParseQuantity(dev.SyncVolumeSize)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid sync volume size: %s", dev.SyncVolumeSize, err)
+		}
+		syncVolume.EmptyDir = &apiv1.EmptyDirVolumeSource{SizeLimit: &size}
+	}
 
 	d.Spec.Template.Spec.Volumes = append(
 		d.Spec.Template.Spec.Volumes,
 		syncVolume,
 	)
+
+	return nil
 }
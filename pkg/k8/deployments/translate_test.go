@@ -1,9 +1,11 @@
 package deployments
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/okteto/cnd/pkg/model"
+	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 )
 
@@ -16,16 +18,18 @@ func Test_updateCNDContainer(t *testing.T) {
 				Image:     "okteto/test",
 			},
 		},
-		Mount: model.Mount{
+		Mounts: []model.Mount{{
 			Source: ".",
 			Target: "/app",
-		},
+		}},
 	}
 	c := &apiv1.Container{
 		Command: []string{"/run"},
 		Args:    []string{"all"},
 	}
-	updateCndContainer(c, dev)
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
 
 	if c.Image != "okteto/test" {
 		t.Errorf("Image wasn't updated: %+v", c)
@@ -48,3 +52,322 @@ func Test_updateCNDContainer(t *testing.T) {
 	}
 
 }
+
+func Test_updateCNDContainerWorkDir(t *testing.T) {
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name:    "deployment",
+				WorkDir: "/app/src",
+			},
+		},
+		Mounts: []model.Mount{{
+			Source: ".",
+			Target: "/app",
+		}},
+	}
+	c := &apiv1.Container{WorkingDir: "/"}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.WorkingDir != "/app/src" {
+		t.Errorf("WorkingDir wasn't updated: %+v", c)
+	}
+
+	if c.VolumeMounts[0].MountPath != "/app" {
+		t.Errorf("CND mount wasn't set: %+v", c)
+	}
+}
+
+func Test_updateCNDContainerReadOnlyMount(t *testing.T) {
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name: "deployment",
+			},
+		},
+		Mounts: []model.Mount{
+			{Source: ".", Target: "/app"},
+			{Source: "./config", Target: "/config", ReadOnly: true},
+		},
+	}
+
+	c := &apiv1.Container{}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.VolumeMounts) != 2 || c.VolumeMounts[0].ReadOnly || !c.VolumeMounts[1].ReadOnly {
+		t.Errorf("readonly mounts weren't set: %+v", c.VolumeMounts)
+	}
+}
+
+func Test_updateCNDContainerCapabilities(t *testing.T) {
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name: "deployment",
+				Capabilities: model.Capabilities{
+					Add: []string{"SYS_PTRACE"},
+				},
+			},
+		},
+		Mounts: []model.Mount{{
+			Target: "/app",
+		}},
+	}
+
+	c := &apiv1.Container{}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+	if c.SecurityContext == nil || len(c.SecurityContext.Capabilities.Add) != 1 || c.SecurityContext.Capabilities.Add[0] != "SYS_PTRACE" {
+		t.Errorf("capabilities weren't updated: %+v", c.SecurityContext)
+	}
+
+	dev.Swap.Deployment.Capabilities = model.Capabilities{}
+	c = &apiv1.Container{}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+	if c.SecurityContext != nil {
+		t.Errorf("capabilities were updated: %+v", c.SecurityContext)
+	}
+}
+
+func Test_updateCNDContainerResources(t *testing.T) {
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name: "deployment",
+				Resources: model.ResourceRequirements{
+					Requests: map[string]string{"cpu": "250m"},
+					Limits:   map[string]string{"memory": "256Mi"},
+				},
+			},
+		},
+		Mounts: []model.Mount{{
+			Target: "/app",
+		}},
+	}
+
+	c := &apiv1.Container{}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+	if cpu := c.Resources.Requests[apiv1.ResourceCPU]; cpu.String() != "250m" {
+		t.Errorf("cpu request wasn't updated: %+v", c.Resources)
+	}
+
+	if memory := c.Resources.Limits[apiv1.ResourceMemory]; memory.String() != "256Mi" {
+		t.Errorf("memory limit wasn't updated: %+v", c.Resources)
+	}
+
+	dev.Swap.Deployment.Resources = model.ResourceRequirements{}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Resources.Requests) != 0 || len(c.Resources.Limits) != 0 {
+		t.Errorf("resources weren't cleared: %+v", c.Resources)
+	}
+
+	dev.Swap.Deployment.Resources = model.ResourceRequirements{Limits: map[string]string{"memory": "a lot"}}
+	if err := updateCndContainer(c, dev); err == nil {
+		t.Errorf("an invalid quantity was accepted")
+	}
+}
+
+func Test_updateCNDContainerEnvironment(t *testing.T) {
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name: "deployment",
+			},
+		},
+		Mounts: []model.Mount{{
+			Target: "/app",
+		}},
+		Environment: []model.EnvVar{{Name: "DEBUG", Value: "true"}, {Name: "PORT", Value: "8080"}},
+	}
+
+	c := &apiv1.Container{Env: []apiv1.EnvVar{{Name: "DEBUG", Value: "false"}, {Name: "HOME", Value: "/root"}}}
+	if err := updateCndContainer(c, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []apiv1.EnvVar{{Name: "DEBUG", Value: "true"}, {Name: "HOME", Value: "/root"}, {Name: "PORT", Value: "8080"}}
+	if !reflect.DeepEqual(c.Env, expected) {
+		t.Errorf("%+v != %+v", c.Env, expected)
+	}
+}
+
+func Test_translateWithoutMount(t *testing.T) {
+	replicas := int32(1)
+	enabled := false
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name:      "deployment",
+				Container: "api",
+				Image:     "okteto/test",
+			},
+		},
+		Mounts: []model.Mount{{
+			Target:  "/app",
+			Enabled: &enabled,
+		}},
+	}
+
+	d := &appsv1.Deployment{}
+	d.Name = "deployment"
+	d.Spec.Replicas = &replicas
+	d.Spec.Template.Spec.Containers = []apiv1.Container{{Name: "api"}}
+
+	if err := translateToDevModeDeployment(d, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := d.Spec.Template.Spec
+	if len(spec.Containers) != 1 || len(spec.InitContainers) != 0 || len(spec.Volumes) != 0 {
+		t.Errorf("sync resources were created: %+v", spec)
+	}
+
+	if spec.Containers[0].Image != "okteto/test" || len(spec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("container wasn't swapped without mounts: %+v", spec.Containers[0])
+	}
+}
+
+func Test_translateMultipleContainers(t *testing.T) {
+	replicas := int32(1)
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name:      "deployment",
+				Container: "app",
+				Image:     "okteto/app",
+				Containers: []model.ContainerSwap{
+					{Name: "worker", Image: "okteto/worker", Command: []string{"make", "worker"}, Target: "/worker"},
+				},
+			},
+		},
+		Mounts: []model.Mount{{
+			Source: ".",
+			Target: "/app",
+		}},
+	}
+
+	d := &appsv1.Deployment{}
+	d.Name = "deployment"
+	d.Spec.Replicas = &replicas
+	d.Spec.Template.Spec.Containers = []apiv1.Container{{Name: "app"}, {Name: "worker"}, {Name: "sidecar", Image: "sidecar"}}
+
+	if err := translateToDevModeDeployment(d, dev); err != nil {
+		t.Fatal(err)
+	}
+
+	containers := d.Spec.Template.Spec.Containers
+	if containers[0].Image != "okteto/app" || containers[0].VolumeMounts[0].MountPath != "/app" {
+		t.Errorf("main container wasn't swapped: %+v", containers[0])
+	}
+
+	if containers[1].Image != "okteto/worker" || containers[1].Command[1] != "worker" || containers[1].VolumeMounts[0].MountPath != "/worker" || containers[1].WorkingDir != "/worker" {
+		t.Errorf("additional container wasn't swapped: %+v", containers[1])
+	}
+
+	if containers[2].Image != "sidecar" || len(containers[2].VolumeMounts) != 0 {
+		t.Errorf("sidecar was swapped: %+v", containers[2])
+	}
+
+	dev.Swap.Deployment.Containers[0].Name = "missing"
+	d.Spec.Template.Spec.Containers = []apiv1.Container{{Name: "app"}}
+	if err := translateToDevModeDeployment(d, dev); err == nil {
+		t.Errorf("missing container was accepted")
+	}
+}
+
+func Test_addImagePullSecrets(t *testing.T) {
+	d := &appsv1.Deployment{}
+	d.Spec.Template.Spec.ImagePullSecrets = []apiv1.LocalObjectReference{{Name: "registry"}}
+	addImagePullSecrets(d, []string{"dev-registry", "registry"})
+
+	expected := []apiv1.LocalObjectReference{{Name: "registry"}, {Name: "dev-registry"}}
+	if !reflect.DeepEqual(d.Spec.Template.Spec.ImagePullSecrets, expected) {
+		t.Errorf("%+v != %+v", d.Spec.Template.Spec.ImagePullSecrets, expected)
+	}
+
+	d = &appsv1.Deployment{}
+	addImagePullSecrets(d, nil)
+	if d.Spec.Template.Spec.ImagePullSecrets != nil {
+		t.Errorf("secrets were added: %+v", d.Spec.Template.Spec.ImagePullSecrets)
+	}
+}
+
+func Test_createSyncthingContainerImage(t *testing.T) {
+	dev := &model.Dev{}
+	d := &appsv1.Deployment{}
+	createSyncthingContainer(d, dev)
+	if image := d.Spec.Template.Spec.Containers[0].Image; image != model.DefaultSyncImage {
+		t.Errorf("%s != %s", image, model.DefaultSyncImage)
+	}
+
+	dev.SyncImage = "registry.local/okteto/syncthing:1.0"
+	d = &appsv1.Deployment{}
+	createSyncthingContainer(d, dev)
+	if image := d.Spec.Template.Spec.Containers[0].Image; image != dev.SyncImage {
+		t.Errorf("%s != %s", image, dev.SyncImage)
+	}
+}
+
+func Test_createSyncthingVolumeSize(t *testing.T) {
+	dev := &model.Dev{}
+	d := &appsv1.Deployment{}
+	if err := createSyncthingVolume(d, dev); err != nil {
+		t.Fatal(err)
+	}
+	if d.Spec.Template.Spec.Volumes[0].EmptyDir != nil {
+		t.Errorf("the volume size was limited: %+v", d.Spec.Template.Spec.Volumes[0])
+	}
+
+	dev.SyncVolumeSize = "10Gi"
+	d = &appsv1.Deployment{}
+	if err := createSyncthingVolume(d, dev); err != nil {
+		t.Fatal(err)
+	}
+	emptyDir := d.Spec.Template.Spec.Volumes[0].EmptyDir
+	if emptyDir == nil || emptyDir.SizeLimit == nil || emptyDir.SizeLimit.String() != "10Gi" {
+		t.Errorf("the volume size wasn't limited: %+v", d.Spec.Template.Spec.Volumes[0])
+	}
+	dev.SyncVolumeSize = "ten gigs"
+	if err := createSyncthingVolume(&appsv1.Deployment{}, dev); err == nil {
+		t.Errorf("an invalid size was accepted")
+	}
+}
+
+func Test_createSyncthingContainerOwner(t *testing.T) {
+	dev := &model.Dev{}
+	d := &appsv1.Deployment{}
+	createInitSyncthingContainer(d, dev)
+	createSyncthingContainer(d, dev)
+	if d.Spec.Template.Spec.InitContainers[0].Command != nil {
+		t.Errorf("the init container command was changed: %v", d.Spec.Template.Spec.InitContainers[0].Command)
+	}
+
+	if d.Spec.Template.Spec.Containers[0].SecurityContext != nil {
+		t.Errorf("the syncthing user was changed: %+v", d.Spec.Template.Spec.Containers[0].SecurityContext)
+	}
+
+	uid := int64(1000)
+	gid := int64(2000)
+	dev.Sync.OwnerUID = &uid
+	dev.Sync.OwnerGID = &gid
+	d = &appsv1.Deployment{}
+	createInitSyncthingContainer(d, dev)
+	createSyncthingContainer(d, dev)
+	if d.Spec.Template.Spec.InitContainers[0].Command != nil {
+		t.Errorf("the init container command was changed: %v", d.Spec.Template.Spec.InitContainers[0].Command)
+	}
+
+	if d.Spec.Template.Spec.Containers[0].SecurityContext != nil {
+		t.Errorf("the syncthing user was changed: %+v", d.Spec.Template.Spec.Containers[0].SecurityContext)
+	}
+}
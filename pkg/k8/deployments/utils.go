@@ -16,6 +16,47 @@ func GetFullName(namespace, deploymentName string) string {
 	return fmt.Sprintf("%s/%s", namespace, deploymentName)
 }
 
+// GetOriginalCommand returns the command and args of the swapped container before activating the cloud native environment
+func GetOriginalCommand(dev *model.Dev, d *appsv1.Deployment) ([]string, []string, error) {
+	dOrig, err := getOriginalDeployment(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dOrig != nil {
+		d = dOrig
+	}
+
+	name := getDevContainerOrFirst(dev.Swap.Deployment.Container, d.Spec.Template.Spec.Containers)
+	for _, c := range d.Spec.Template.Spec.Containers {
+		if c.Name == name {
+			return c.Command, c.Args, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("container %s doesn't exist in the deployment %s", name, d.Name)
+}
+
+// getOriginalDeployment returns the deployment before activating the cloud native environment, or nil if it isn't active
+func getOriginalDeployment(d *appsv1.Deployment) (*appsv1.Deployment, error) {
+	annotation := getAnnotation(d.GetObjectMeta(), model.CNDDeploymentAnnotation)
+	if annotation == "" {
+		return nil, nil
+	}
+
+	manifest, err := model.DecodeManifestAnnotation(annotation)
+	if err != nil {
+		return nil, err
+	}
+
+	dOrig := &appsv1.Deployment{}
+	if err := json.Unmarshal(manifest, dOrig); err != nil {
+		return nil, err
+	}
+
+	return dOrig, nil
+}
+
 func isContainerInPod(pod *apiv1.Pod, container string) bool {
 	for _, c := range pod.Spec.Containers {
 		if c.Name == container {
@@ -77,12 +118,53 @@ func GetDevFromAnnotation(d *appsv1.Deployment) (*model.Dev, error) {
 	return nil, fmt.Errorf("the deployment '%s' is not a cloud native environment", d.Name)
 }
 
-func setDevAsAnnotation(d *appsv1.Deployment, dev *model.Dev) error {
-	devBytes, err := json.Marshal(dev)
+// checkNotSwapped returns an error if the deployment is already swapped by a dev of another container.
+// The sync objects and the manifest annotation have fixed names, so a second swap would overwrite the first one
+func checkNotSwapped(d *appsv1.Deployment, dev *model.Dev) error {
+	if getAnnotation(d.GetObjectMeta(), model.CNDDevAnnotation) == "" {
+		return nil
+	}
+
+	active, err := GetDevFromAnnotation(d)
 	if err != nil {
 		return err
 	}
-	setAnnotation(d.GetObjectMeta(), model.CNDDevAnnotation, string(devBytes))
+
+	if active.Swap.Deployment.Container != dev.Swap.Deployment.Container {
+		return fmt.Errorf("the container '%s' of the deployment '%s' is already swapped, run 'cnd down' on it before swapping the container '%s'", active.Swap.Deployment.Container, d.Name, dev.Swap.Deployment.Container)
+	}
+
+	return nil
+}
+
+// CNDContainerNames returns the names of the containers, init containers and volumes that cnd added to the deployment.
+// They're read from the dev stored in its annotations when it was activated, so teardown doesn't depend on the current manifest
+func CNDContainerNames(d *appsv1.Deployment) ([]string, error) {
+	dev, err := GetDevFromAnnotation(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, r := range dev.TeardownChecklist() {
+		switch r.Type {
+		case model.ResourceContainer, model.ResourceInitContainer, model.ResourceVolume:
+			names = append(names, r.Name)
+		}
+	}
+
+	return names, nil
+}
+
+func setDevAnnotations(d *appsv1.Deployment, dev *model.Dev, manifest []byte) error {
+	annotations, err := dev.Annotations(manifest)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range annotations {
+		setAnnotation(d.GetObjectMeta(), k, v)
+	}
 	return nil
 }
 
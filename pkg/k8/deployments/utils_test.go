@@ -0,0 +1,126 @@
+package deployments
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func Test_GetOriginalCommand(t *testing.T) {
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "deployment", Container: "api"}}}
+
+	d := &appsv1.Deployment{}
+	d.Name = "deployment"
+	d.Spec.Template.Spec.Containers = []apiv1.Container{
+		{Name: "api", Command: []string{"python"}, Args: []string{"app.py"}},
+	}
+
+	command, args, err := GetOriginalCommand(dev, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(command) != 1 || command[0] != "python" || len(args) != 1 || args[0] != "app.py" {
+		t.Errorf("wrong original command: %v %v", command, args)
+	}
+
+	manifest, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	swapped := d.DeepCopy()
+	swapped.Spec.Template.Spec.Containers[0].Command = []string{"tail", "-f", "/dev/null"}
+	setAnnotation(swapped.GetObjectMeta(), model.CNDDeploymentAnnotation, string(manifest))
+
+	command, _, err = GetOriginalCommand(dev, swapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(command) != 1 || command[0] != "python" {
+		t.Errorf("original command wasn't read from the annotation: %v", command)
+	}
+
+	encoded, err := model.EncodeManifestAnnotation(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setAnnotation(swapped.GetObjectMeta(), model.CNDDeploymentAnnotation, encoded)
+	command, _, err = GetOriginalCommand(dev, swapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(command) != 1 || command[0] != "python" {
+		t.Errorf("original command wasn't read from the encoded annotation: %v", command)
+	}
+
+	dev.Swap.Deployment.Container = "missing"
+	if _, _, err := GetOriginalCommand(dev, d); err == nil {
+		t.Errorf("missing container was accepted")
+	}
+}
+
+func Test_CNDContainerNames(t *testing.T) {
+	d := &appsv1.Deployment{}
+	d.Name = "deployment"
+	if _, err := CNDContainerNames(d); err == nil {
+		t.Errorf("deployment without annotations was accepted")
+	}
+
+	activated := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "deployment", Container: "api"}},
+		Mounts: []model.Mount{{Source: ".", Target: "/app"}},
+	}
+	if err := setDevAnnotations(d, activated, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := CNDContainerNames(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{model.CNDInitSyncContainerName, model.CNDSyncContainerName, model.CNDSyncVolumeName}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("%v != %v", names, expected)
+	}
+
+	enabled := false
+	activated.Mounts[0].Enabled = &enabled
+	if err := setDevAnnotations(d, activated, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	if names, err := CNDContainerNames(d); err != nil || len(names) != 0 {
+		t.Errorf("sync names were returned without synched mounts: %v %v", names, err)
+	}
+}
+
+func Test_checkNotSwapped(t *testing.T) {
+	d := &appsv1.Deployment{}
+	d.Name = "deployment"
+	api := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "deployment", Container: "api"}}}
+	if err := checkNotSwapped(d, api); err != nil {
+		t.Errorf("deployment without annotations was rejected: %s", err)
+	}
+
+	if err := setDevAnnotations(d, api, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkNotSwapped(d, api); err != nil {
+		t.Errorf("the swap of the same container was rejected: %s", err)
+	}
+
+	worker := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "deployment", Container: "worker"}}}
+	if err := checkNotSwapped(d, worker); err == nil {
+		t.Errorf("the second swap of the deployment was accepted")
+	}
+}
@@ -64,8 +64,7 @@ func GetDevConfig(language string) *model.Dev {
 	dev := model.NewDev()
 	dev.Swap.Deployment.Image = vals.image
 	dev.Swap.Deployment.Command = vals.command
-	dev.Mount.Source = "."
-	dev.Mount.Target = vals.path
+	dev.Mounts = []model.Mount{{Source: ".", Target: vals.path}}
 	dev.Scripts = vals.scripts
 	dev.Scripts[helloCommandName] = "echo Your cluster ♥ you"
 	return dev
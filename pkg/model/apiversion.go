@@ -0,0 +1,38 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CurrentAPIVersion is the manifest version of this binary, the default of manifests without an apiVersion
+const CurrentAPIVersion = "v1"
+
+// validateAPIVersion checks that the manifest version is supported by this binary
+func validateAPIVersion(version string) error {
+	if version == CurrentAPIVersion {
+		return nil
+	}
+
+	n, err := parseAPIVersion(version)
+	if err != nil {
+		return fmt.Errorf("apiVersion '%s' is not valid, use '%s'", version, CurrentAPIVersion)
+	}
+
+	current, _ := parseAPIVersion(CurrentAPIVersion)
+	if n > current {
+		return fmt.Errorf("apiVersion '%s' is newer than the supported '%s', upgrade cnd to read this manifest", version, CurrentAPIVersion)
+	}
+
+	return fmt.Errorf("apiVersion '%s' is not supported, use '%s'", version, CurrentAPIVersion)
+}
+
+// parseAPIVersion returns the number of a version like v1
+func parseAPIVersion(version string) (int, error) {
+	if !strings.HasPrefix(version, "v") {
+		return 0, fmt.Errorf("'%s' doesn't start with 'v'", version)
+	}
+
+	return strconv.Atoi(version[1:])
+}
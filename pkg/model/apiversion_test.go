@@ -0,0 +1,41 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_loadDevAPIVersion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{name: "missing", version: ""},
+		{name: "current", version: "apiVersion: v1"},
+		{name: "newer", version: "apiVersion: v2", expected: "upgrade cnd"},
+		{name: "older", version: "apiVersion: v0", expected: "not supported"},
+		{name: "invalid", version: "apiVersion: latest", expected: "not valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.version + "\nswap:\n  deployment:\n    name: api"))
+			if tt.expected == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if d.APIVersion != CurrentAPIVersion {
+					t.Errorf("%s != %s", d.APIVersion, CurrentAPIVersion)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("'%s' wasn't rejected with '%s': %v", tt.version, tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities are the linux capabilities added to or dropped from the dev container
+type Capabilities struct {
+	Add  []string `json:"add,omitempty" yaml:"add,omitempty"`
+	Drop []string `json:"drop,omitempty" yaml:"drop,omitempty"`
+}
+
+// linuxCapabilities are the capability names supported by kubernetes, without the CAP_ prefix
+var linuxCapabilities = map[string]bool{
+	"ALL":              true,
+	"AUDIT_CONTROL":    true,
+	"AUDIT_READ":       true,
+	"AUDIT_WRITE":      true,
+	"BLOCK_SUSPEND":    true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"DAC_READ_SEARCH":  true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"IPC_LOCK":         true,
+	"IPC_OWNER":        true,
+	"KILL":             true,
+	"LEASE":            true,
+	"LINUX_IMMUTABLE":  true,
+	"MAC_ADMIN":        true,
+	"MAC_OVERRIDE":     true,
+	"MKNOD":            true,
+	"NET_ADMIN":        true,
+	"NET_BIND_SERVICE": true,
+	"NET_BROADCAST":    true,
+	"NET_RAW":          true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYSLOG":           true,
+	"SYS_ADMIN":        true,
+	"SYS_BOOT":         true,
+	"SYS_CHROOT":       true,
+	"SYS_MODULE":       true,
+	"SYS_NICE":         true,
+	"SYS_PACCT":        true,
+	"SYS_PTRACE":       true,
+	"SYS_RAWIO":        true,
+	"SYS_RESOURCE":     true,
+	"SYS_TIME":         true,
+	"SYS_TTY_CONFIG":   true,
+	"WAKE_ALARM":       true,
+}
+
+// IsEmpty returns true when no capabilities are added or dropped
+func (c Capabilities) IsEmpty() bool {
+	return len(c.Add) == 0 && len(c.Drop) == 0
+}
+
+func (c Capabilities) validate() error {
+	for _, list := range [][]string{c.Add, c.Drop} {
+		for _, name := range list {
+			if !linuxCapabilities[strings.TrimPrefix(name, "CAP_")] {
+				return fmt.Errorf("'%s' is not a known linux capability", name)
+			}
+		}
+	}
+
+	return nil
+}
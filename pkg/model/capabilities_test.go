@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+)
+
+func Test_Capabilities_validate(t *testing.T) {
+	var tests = []struct {
+		name         string
+		capabilities Capabilities
+		fails        bool
+	}{
+		{name: "empty", capabilities: Capabilities{}},
+		{name: "known", capabilities: Capabilities{Add: []string{"SYS_PTRACE"}, Drop: []string{"CAP_NET_RAW"}}},
+		{name: "unknown-add", capabilities: Capabilities{Add: []string{"SYS_DEBUG"}}, fails: true},
+		{name: "unknown-drop", capabilities: Capabilities{Drop: []string{"sys_ptrace"}}, fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.capabilities.validate()
+			if tt.fails && err == nil {
+				t.Errorf("%+v was accepted", tt.capabilities)
+			}
+
+			if !tt.fails && err != nil {
+				t.Errorf("%+v was rejected: %s", tt.capabilities, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Command is the command of the dev container. It's either a list of words or a single string split like a shell does
+type Command []string
+
+// UnmarshalYAML accepts both a list of words and a single command string, e.g. 'npm run dev'
+func (c *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err == nil {
+		words, err := splitShellWords(raw)
+		if err != nil {
+			return err
+		}
+
+		*c = words
+		return nil
+	}
+
+	var words []string
+	if err := unmarshal(&words); err != nil {
+		return err
+	}
+
+	*c = words
+	return nil
+}
+
+// UnmarshalJSON accepts both a list of words and a single command string, e.g. 'npm run dev'
+func (c *Command) UnmarshalJSON(b []byte) error {
+	return c.UnmarshalYAML(func(v interface{}) error {
+		return json.Unmarshal(b, v)
+	})
+}
+
+// splitShellWords splits a command into words like a POSIX shell, keeping quoted and escaped whitespace.
+// Expansions, pipes and redirections are not supported
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			// in double quotes, the backslash only escapes the characters with a special meaning
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("command '%s' has an unterminated quote or escape", s)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// validateEmptyElements rejects the empty elements of a command or its args. description names them in the errors
+func (dev *Dev) validateEmptyElements(field, description string, words []string) []*FieldError {
+	var errs []*FieldError
+	for i, w := range words {
+		if w == "" {
+			errs = append(errs, dev.fieldErrorf(field, "%s cannot have empty elements, element %d is empty", description, i))
+		}
+	}
+
+	return errs
+}
+
+// validateControlCharacters rejects the null bytes and control characters of the commands, the args and the scripts,
+// e.g. pasted from a terminal, since they would only fail when the container starts. Scripts can have tabs and new lines
+func (dev *Dev) validateControlCharacters() []*FieldError {
+	var errs []*FieldError
+	errs = append(errs, dev.validateWordsControlCharacters("swap.deployment.command", "Swap deployment command", dev.Swap.Deployment.Command)...)
+	errs = append(errs, dev.validateWordsControlCharacters("swap.deployment.args", "Swap deployment args", dev.Swap.Deployment.Args)...)
+	for _, c := range dev.Swap.Deployment.Containers {
+		description := fmt.Sprintf("Swap deployment container '%s'", c.Name)
+		errs = append(errs, dev.validateWordsControlCharacters("swap.deployment.containers", description+" command", c.Command)...)
+		errs = append(errs, dev.validateWordsControlCharacters("swap.deployment.containers", description+" args", c.Args)...)
+	}
+
+	for _, name := range dev.ScriptNames() {
+		if offset, r, ok := findControlCharacter(dev.Scripts[name], true); ok {
+			errs = append(errs, dev.fieldErrorf("scripts."+name, "Script '%s' has the control character %U at byte %d", name, r, offset))
+		}
+	}
+
+	return errs
+}
+
+// validateWordsControlCharacters rejects the control characters of the elements of a command or its args
+func (dev *Dev) validateWordsControlCharacters(field, description string, words []string) []*FieldError {
+	var errs []*FieldError
+	for i, w := range words {
+		if offset, r, ok := findControlCharacter(w, false); ok {
+			errs = append(errs, dev.fieldErrorf(field, "%s element %d has the control character %U at byte %d", description, i, r, offset))
+		}
+	}
+
+	return errs
+}
+
+// findControlCharacter returns the byte offset of the first control character of s, including null bytes.
+// Tabs and new lines are allowed if allowWhitespace is set
+func findControlCharacter(s string, allowWhitespace bool) (int, rune, bool) {
+	for i, r := range s {
+		if !unicode.IsControl(r) {
+			continue
+		}
+
+		if allowWhitespace && (r == '\t' || r == '\n' || r == '\r') {
+			continue
+		}
+
+		return i, r, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_splitShellWords(t *testing.T) {
+	var tests = []struct {
+		name     string
+		command  string
+		expected []string
+		valid    bool
+	}{
+		{name: "words", command: "npm run dev", expected: []string{"npm", "run", "dev"}, valid: true},
+		{name: "whitespace", command: "  npm\trun   dev ", expected: []string{"npm", "run", "dev"}, valid: true},
+		{name: "single-quotes", command: `sh -c 'echo "hi there"'`, expected: []string{"sh", "-c", `echo "hi there"`}, valid: true},
+		{name: "double-quotes", command: `echo "a \"b\" \n" c`, expected: []string{"echo", `a "b" \n`, "c"}, valid: true},
+		{name: "escaped-space", command: `ls my\ folder`, expected: []string{"ls", "my folder"}, valid: true},
+		{name: "empty-quotes", command: `echo ""`, expected: []string{"echo", ""}, valid: true},
+		{name: "unterminated-quote", command: `echo "hi`, valid: false},
+		{name: "unterminated-escape", command: `echo \`, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := splitShellWords(tt.command)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("invalid command was split: %q", words)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(words, tt.expected) {
+				t.Errorf("%q != %q", words, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_loadDevCommandString(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected []string
+	}{
+		{
+			name: "string",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    command: npm run "dev server"`,
+			expected: []string{"npm", "run", "dev server"},
+		},
+		{
+			name: "list",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    command: ["npm", "run", "dev"]`,
+			expected: []string{"npm", "run", "dev"},
+		},
+		{
+			name:     "json",
+			manifest: `{"swap": {"deployment": {"name": "deployment", "command": "npm run dev"}}}`,
+			expected: []string{"npm", "run", "dev"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual([]string(d.Swap.Deployment.Command), tt.expected) {
+				t.Errorf("%q != %q", d.Swap.Deployment.Command, tt.expected)
+			}
+		})
+	}
+
+	manifest := `
+swap:
+  deployment:
+    name: deployment
+    container: app
+    containers:
+      - name: worker
+        command: python "worker.py"`
+	d, err := LoadDev([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"python", "worker.py"}; !reflect.DeepEqual([]string(d.Swap.Deployment.Containers[0].Command), expected) {
+		t.Errorf("%q != %q", d.Swap.Deployment.Containers[0].Command, expected)
+	}
+}
+
+func Test_validateControlCharacters(t *testing.T) {
+	var tests = []struct {
+		name       string
+		command    []string
+		args       []string
+		containers []ContainerSwap
+		scripts    map[string]string
+		expected   string
+	}{
+		{name: "valid", command: []string{"npm", "run", "dev"}, args: []string{"--port", "8080"}, scripts: map[string]string{"build": "make\tbuild\nmake test"}},
+		{name: "null-command", command: []string{"npm", "ru\x00n"}, expected: "Swap deployment command element 1 has the control character U+0000 at byte 2"},
+		{name: "escape-args", args: []string{"\x1b[Adev"}, expected: "Swap deployment args element 0 has the control character U+001B at byte 0"},
+		{name: "tab-args", args: []string{"a\tb"}, expected: "Swap deployment args element 0 has the control character U+0009 at byte 1"},
+		{name: "bell-script", scripts: map[string]string{"build": "make\a"}, expected: "Script 'build' has the control character U+0007 at byte 4"},
+		{name: "null-container-command", containers: []ContainerSwap{{Name: "worker", Command: Command{"pyth\x00on"}}}, expected: "Swap deployment container 'worker' command element 0 has the control character U+0000 at byte 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Command: tt.command, Args: tt.args, Containers: tt.containers}}, Scripts: tt.scripts}
+			errs := dev.validateControlCharacters()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid values were rejected: %v", errs)
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
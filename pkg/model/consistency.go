@@ -0,0 +1,50 @@
+package model
+
+import (
+	"fmt"
+)
+
+// Consistency returns warnings about likely mistakes of a valid dev, e.g. a customized command without a container.
+// They don't prevent activating the dev
+func (dev *Dev) Consistency() []string {
+	var warnings []string
+	if dev.Swap.Deployment.Container == "" && (len(dev.Swap.Deployment.Command) > 0 || len(dev.Swap.Deployment.Args) > 0) {
+		warnings = append(warnings, "swap.deployment.command or args are set without swap.deployment.container, they replace the ones of the first container of the deployment")
+	}
+
+	d := dev.Swap.Deployment
+	if Validation.WarnImplicitEntrypoint && d.Image != "" && len(d.Command) == 0 && len(d.Args) == 0 {
+		warnings = append(warnings, fmt.Sprintf("the deployment %s swaps the image %s without a command or args, it relies on the entrypoint of the image", d.Name, d.Image))
+	}
+
+	if !dev.IsSynched() {
+		return warnings
+	}
+
+	names := dev.Names()
+	for _, n := range []struct{ kind, name string }{
+		{"volume", names.Volume},
+		{"mount", names.Mount},
+		{"init container", names.InitContainer},
+		{"container", names.Container},
+	} {
+		if n.name == "" {
+			warnings = append(warnings, fmt.Sprintf("the name of the sync %s is empty", n.kind))
+		}
+	}
+
+	workDir := dev.GetWorkDir()
+	inMount := false
+	for _, m := range dev.EnabledMounts() {
+		if containsPath(m.Target, workDir) {
+			inMount = true
+			break
+		}
+	}
+
+	if !inMount {
+		warnings = append(warnings, fmt.Sprintf("swap.deployment.workdir %s is not inside a mount target, the synched files are not in the working directory", workDir))
+	}
+
+	return warnings
+}
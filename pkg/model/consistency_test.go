@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Consistency(t *testing.T) {
+	disabled := false
+	var tests = []struct {
+		name     string
+		dev      *Dev
+		expected []string
+	}{
+		{
+			name: "consistent",
+			dev: &Dev{
+				Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", Command: Command{"make"}, WorkDir: "/app/src"}},
+				Mounts: []Mount{{Source: ".", Target: "/app"}},
+			},
+		},
+		{
+			name: "command-without-container",
+			dev: &Dev{
+				Swap:   Swap{Deployment: Deployment{Name: "api", Args: []string{"--debug"}}},
+				Mounts: []Mount{{Source: ".", Target: "/app"}},
+			},
+			expected: []string{"without swap.deployment.container"},
+		},
+		{
+			name: "workdir-outside-mounts",
+			dev: &Dev{
+				Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", WorkDir: "/srv"}},
+				Mounts: []Mount{{Source: ".", Target: "/app"}},
+			},
+			expected: []string{"workdir /srv is not inside a mount target"},
+		},
+		{
+			name: "not-synched",
+			dev: &Dev{
+				Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", WorkDir: "/srv"}},
+				Mounts: []Mount{{Source: ".", Target: "/app", Enabled: &disabled}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := tt.dev.Consistency()
+			if len(warnings) != len(tt.expected) {
+				t.Fatalf("wrong warnings: %v", warnings)
+			}
+
+			for i := range warnings {
+				if !strings.Contains(warnings[i], tt.expected[i]) {
+					t.Errorf("'%s' doesn't contain '%s'", warnings[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_ConsistencyImplicitEntrypoint(t *testing.T) {
+	defer func() { Validation.WarnImplicitEntrypoint = false }()
+
+	dev := &Dev{
+		Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", Image: "busybox"}},
+		Mounts: []Mount{{Source: ".", Target: "/app"}},
+	}
+
+	if warnings := dev.Consistency(); len(warnings) != 0 {
+		t.Errorf("the warning is not optional: %v", warnings)
+	}
+
+	Validation.WarnImplicitEntrypoint = true
+	warnings := dev.Consistency()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "the deployment api swaps the image busybox without a command or args") {
+		t.Errorf("wrong warnings: %v", warnings)
+	}
+
+	dev.Swap.Deployment.Args = []string{"sleep", "infinity"}
+	if warnings := dev.Consistency(); len(warnings) != 0 {
+		t.Errorf("args were ignored: %v", warnings)
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContainerSwap is an additional container of the deployment swapped by the dev. It shares the synched folders with the main container
+type ContainerSwap struct {
+	Name    string   `json:"name" yaml:"name"`
+	Image   string   `json:"image,omitempty" yaml:"image,omitempty"`
+	Command Command  `json:"command,omitempty" yaml:"command,omitempty"`
+	Args    []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Target  string   `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+// ContainerMounts returns the enabled mounts of a swapped container. The target of the main mount can be overridden per container
+func (dev *Dev) ContainerMounts(container string) []Mount {
+	mounts := dev.EnabledMounts()
+	if swap := dev.containerSwap(container); swap != nil && swap.Target != "" && len(mounts) > 0 {
+		mounts[0].Target = swap.Target
+	}
+
+	for i := range mounts {
+		mounts[i].Target = cleanTarget(mounts[i].Target)
+	}
+
+	return mounts
+}
+
+// ContainerWorkDir returns the working directory of a swapped container, its mount target if overridden
+func (dev *Dev) ContainerWorkDir(container string) string {
+	if swap := dev.containerSwap(container); swap != nil && swap.Target != "" {
+		return cleanTarget(swap.Target)
+	}
+
+	return dev.GetWorkDir()
+}
+
+func (dev *Dev) containerSwap(name string) *ContainerSwap {
+	for i := range dev.Swap.Deployment.Containers {
+		if dev.Swap.Deployment.Containers[i].Name == name {
+			return &dev.Swap.Deployment.Containers[i]
+		}
+	}
+
+	return nil
+}
+
+func (dev *Dev) validateContainers() []*FieldError {
+	var errs []*FieldError
+	names := map[string]bool{}
+	if dev.Swap.Deployment.Container != "" {
+		names[dev.Swap.Deployment.Container] = true
+	}
+
+	for i, c := range dev.Swap.Deployment.Containers {
+		field := "swap.deployment.containers"
+		if c.Name == "" {
+			errs = append(errs, dev.fieldErrorf(field, "Swap deployment container %d must have a name", i))
+			continue
+		}
+
+		if names[c.Name] {
+			errs = append(errs, dev.fieldErrorf(field, "Swap deployment container '%s' is swapped more than once", c.Name))
+		}
+		names[c.Name] = true
+
+		if c.Image != "" {
+			if err := validateImage(c.Image); err != nil {
+				errs = append(errs, dev.fieldErrorf(field, "%s", err))
+			}
+		}
+
+		description := fmt.Sprintf("Swap deployment container '%s'", c.Name)
+		errs = append(errs, dev.validateEmptyElements(field, description+" command", c.Command)...)
+		errs = append(errs, dev.validateEmptyElements(field, description+" args", c.Args)...)
+
+		if c.Target != "" && !strings.HasPrefix(c.Target, "/") {
+			errs = append(errs, dev.fieldErrorf(field, "Swap deployment container '%s' target must be an absolute path, got %q", c.Name, c.Target))
+		} else if c.Target != "" && containsPath(c.Target, CNDSyncMountPath) {
+			errs = append(errs, dev.fieldErrorf(field, "Swap deployment container '%s' target %s cannot be or contain the sync mount path %s", c.Name, c.Target, CNDSyncMountPath))
+		}
+	}
+
+	if len(dev.Swap.Deployment.Containers) > 0 && dev.Swap.Deployment.Container == "" {
+		errs = append(errs, dev.fieldErrorf("swap.deployment.container", "Swap deployment container is required when swapping additional containers"))
+	}
+
+	return errs
+}
+
+// ResolveContainer sets the container to swap when it's not defined and the deployment has a single container.
+// containers are the names of the containers of the deployment, listed by the caller with its kubernetes client
+func (dev *Dev) ResolveContainer(containers []string) error {
+	if dev.Swap.Deployment.Container != "" {
+		return nil
+	}
+
+	switch len(containers) {
+	case 0:
+		return fmt.Errorf("the deployment '%s' doesn't have any containers", dev.Swap.Deployment.Name)
+	case 1:
+		dev.Swap.Deployment.Container = containers[0]
+		return nil
+	default:
+		return fmt.Errorf("the deployment '%s' has several containers, set swap.deployment.container to one of them: %s", dev.Swap.Deployment.Name, strings.Join(containers, ", "))
+	}
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_validateContainers(t *testing.T) {
+	var tests = []struct {
+		name       string
+		container  string
+		containers []ContainerSwap
+		errors     int
+	}{
+		{name: "none", errors: 0},
+		{name: "valid", container: "app", containers: []ContainerSwap{{Name: "worker"}, {Name: "cron", Image: "okteto/cron"}}, errors: 0},
+		{name: "empty-name", container: "app", containers: []ContainerSwap{{Image: "okteto/worker"}}, errors: 1},
+		{name: "duplicated", container: "app", containers: []ContainerSwap{{Name: "worker"}, {Name: "worker"}}, errors: 1},
+		{name: "duplicated-main", container: "app", containers: []ContainerSwap{{Name: "app"}}, errors: 1},
+		{name: "invalid-image", container: "app", containers: []ContainerSwap{{Name: "worker", Image: "my worker"}}, errors: 1},
+		{name: "missing-main", containers: []ContainerSwap{{Name: "worker"}}, errors: 1},
+		{name: "target", container: "app", containers: []ContainerSwap{{Name: "worker", Target: "/worker"}}, errors: 0},
+		{name: "relative-target", container: "app", containers: []ContainerSwap{{Name: "worker", Target: "worker"}}, errors: 1},
+		{name: "sync-target", container: "app", containers: []ContainerSwap{{Name: "worker", Target: "/var"}}, errors: 1},
+		{name: "empty-command-element", container: "app", containers: []ContainerSwap{{Name: "worker", Command: Command{"python", ""}}}, errors: 1},
+		{name: "empty-args-element", container: "app", containers: []ContainerSwap{{Name: "worker", Args: []string{""}}}, errors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment", Container: tt.container, Containers: tt.containers}}}
+			if errs := dev.validateContainers(); len(errs) != tt.errors {
+				t.Errorf("wrong errors, expected %d: %v", tt.errors, errs)
+			}
+		})
+	}
+}
+
+func Test_ContainerMounts(t *testing.T) {
+	dev := &Dev{
+		Swap: Swap{Deployment: Deployment{
+			Name:       "deployment",
+			Container:  "app",
+			Containers: []ContainerSwap{{Name: "worker", Target: "/worker"}, {Name: "cron"}},
+		}},
+		Mounts: []Mount{{Source: "/code", Target: "/app"}, {Source: "/proto", Target: "/proto"}},
+	}
+
+	var tests = []struct {
+		container string
+		targets   []string
+		workdir   string
+	}{
+		{container: "app", targets: []string{"/app", "/proto"}, workdir: "/app"},
+		{container: "worker", targets: []string{"/worker", "/proto"}, workdir: "/worker"},
+		{container: "cron", targets: []string{"/app", "/proto"}, workdir: "/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.container, func(t *testing.T) {
+			var targets []string
+			for _, m := range dev.ContainerMounts(tt.container) {
+				targets = append(targets, m.Target)
+			}
+
+			if !reflect.DeepEqual(targets, tt.targets) {
+				t.Errorf("%v != %v", targets, tt.targets)
+			}
+
+			if workdir := dev.ContainerWorkDir(tt.container); workdir != tt.workdir {
+				t.Errorf("%s != %s", workdir, tt.workdir)
+			}
+		})
+	}
+
+	if dev.Mounts[0].Target != "/app" {
+		t.Errorf("the mounts of the dev were modified: %+v", dev.Mounts)
+	}
+}
+
+func Test_ResolveContainer(t *testing.T) {
+	var tests = []struct {
+		name       string
+		container  string
+		containers []string
+		expected   string
+		valid      bool
+	}{
+		{name: "single", containers: []string{"api"}, expected: "api", valid: true},
+		{name: "several", containers: []string{"api", "sidecar"}, expected: "", valid: false},
+		{name: "none", containers: nil, expected: "", valid: false},
+		{name: "defined", container: "sidecar", containers: []string{"api", "sidecar"}, expected: "sidecar", valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment", Container: tt.container}}}
+			err := dev.ResolveContainer(tt.containers)
+			if tt.valid && err != nil {
+				t.Errorf("the container wasn't resolved: %s", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("the container was resolved from %v", tt.containers)
+			}
+
+			if dev.Swap.Deployment.Container != tt.expected {
+				t.Errorf("%s != %s", dev.Swap.Deployment.Container, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Deprecation is a deprecated manifest field used by a dev
+type Deprecation struct {
+	// Field is the path of the deprecated field, e.g. mount
+	Field string
+
+	// Replacement is the field to use instead
+	Replacement string
+
+	// Line is the line of the manifest where the field is used, zero if unknown
+	Line int
+}
+
+func (d Deprecation) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("'%s' (line %d) is deprecated, use '%s' instead", d.Field, d.Line, d.Replacement)
+	}
+
+	return fmt.Sprintf("'%s' is deprecated, use '%s' instead", d.Field, d.Replacement)
+}
+
+// deprecatedFields maps the deprecated manifest fields to their replacement
+var deprecatedFields = map[string]string{
+	"mount": "mounts",
+}
+
+// Deprecations returns the deprecated fields used by the manifest the dev was loaded from
+func (dev *Dev) Deprecations() []Deprecation {
+	return dev.deprecations
+}
+
+func getDeprecations(positions map[string]position) []Deprecation {
+	var deprecations []Deprecation
+	for field, replacement := range deprecatedFields {
+		if p, ok := positions[field]; ok {
+			deprecations = append(deprecations, Deprecation{Field: field, Replacement: replacement, Line: p.line})
+		}
+	}
+
+	sort.Slice(deprecations, func(i, j int) bool {
+		return deprecations[i].Field < deprecations[j].Field
+	})
+
+	return deprecations
+}
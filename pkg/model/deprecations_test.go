@@ -0,0 +1,41 @@
+package model
+
+import (
+	"testing"
+)
+
+func Test_Deprecations(t *testing.T) {
+	deprecatedFields["swap.deployment.container"] = "swap.deployment.containers"
+	defer delete(deprecatedFields, "swap.deployment.container")
+
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+    container: api`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deprecations := d.Deprecations()
+	if len(deprecations) != 1 {
+		t.Fatalf("wrong deprecations: %+v", deprecations)
+	}
+
+	expected := Deprecation{Field: "swap.deployment.container", Replacement: "swap.deployment.containers", Line: 5}
+	if deprecations[0] != expected {
+		t.Errorf("%+v != %+v", deprecations[0], expected)
+	}
+
+	d, err = LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Deprecations()) != 0 {
+		t.Errorf("unused deprecated field was reported: %+v", d.Deprecations())
+	}
+}
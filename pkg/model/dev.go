@@ -1,14 +1,22 @@
 package model
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -29,115 +37,916 @@ const (
 
 	// CNDSyncVolumeName is the name of synched volume
 	CNDSyncVolumeName = "cnd-sync"
+
+	// CNDSyncMountPath is where the synched volume is mounted in the syncthing container
+	CNDSyncMountPath = "/var/cnd-sync"
+
+	// DefaultSyncImage is the image of the container running syncthing
+	DefaultSyncImage = "okteto/syncthing:latest"
+
+	// DefaultInitSyncImage is the image of the init container preparing the synched volume
+	DefaultInitSyncImage = "okteto/init-syncthing:0.3.4"
+
+	// SyncModeTwoWay synchronizes the local and remote changes, it's the default sync mode
+	SyncModeTwoWay = "two-way"
+
+	// SyncModeSendOnly only sends the local changes to the container
+	SyncModeSendOnly = "send-only"
+
+	// SyncModeReceiveOnly only receives the changes of the container
+	SyncModeReceiveOnly = "receive-only"
+
+	// DefaultSyncIdleThreshold is how long the synched files must stay unchanged to consider the sync idle
+	DefaultSyncIdleThreshold = 3 * time.Second
 )
 
 //Dev represents a cloud native development environment
 type Dev struct {
-	Swap    Swap              `yaml:"swap"`
-	Mount   Mount             `yaml:"mount"`
-	Scripts map[string]string `yaml:"scripts"`
+	APIVersion     string            `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	Swap           Swap              `json:"swap" yaml:"swap"`
+	Mounts         []Mount           `json:"mounts" yaml:"mounts"`
+	Sync           Sync              `json:"sync,omitempty" yaml:"sync,omitempty"`
+	Scripts        map[string]string `json:"scripts" yaml:"scripts"`
+	Editor         string            `json:"editor,omitempty" yaml:"editor,omitempty"`
+	Ports          []string          `json:"forward,omitempty" yaml:"forward,omitempty"`
+	Environment    []EnvVar          `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Ignore         []string          `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+	Lifecycle      Lifecycle         `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
+	Down           []string          `json:"down,omitempty" yaml:"down,omitempty"`
+	Healthcheck    *Healthcheck      `json:"healthcheck,omitempty" yaml:"healthcheck,omitempty"`
+	SyncImage      string            `json:"syncImage,omitempty" yaml:"syncImage,omitempty"`
+	SyncVolumeSize string            `json:"syncVolumeSize,omitempty" yaml:"syncVolumeSize,omitempty"`
+	Include        []string          `json:"include,omitempty" yaml:"include,omitempty"`
+
+	positions    map[string]position
+	deprecations []Deprecation
+	scriptFiles  map[string]string
+
+	// manifestPath is the absolute path of the manifest file the dev was read from, if any
+	manifestPath string
+
+	// initSyncImage replaces DefaultInitSyncImage, it's set by ApplyRegistryMirror
+	initSyncImage string
 }
 
 //Swap represents the metadata for the container to be swapped
 type Swap struct {
-	Deployment Deployment `yaml:"deployment"`
+	Deployment Deployment `json:"deployment" yaml:"deployment"`
+
+	// Selector are the labels of the deployment, instead of its name, e.g. for names generated by helm.
+	// It's resolved to the name of a deployment with ResolveSelector
+	Selector map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"`
 }
 
 //Deployment represents the container to be swapped
 type Deployment struct {
-	Name      string   `yaml:"name"`
-	Container string   `yaml:"container,omitempty"`
-	Image     string   `yaml:"image"`
-	Command   []string `yaml:"command,omitempty"`
-	Args      []string `yaml:"args,omitempty"`
+	Name             string               `json:"name" yaml:"name"`
+	Container        string               `json:"container,omitempty" yaml:"container,omitempty"`
+	Image            string               `json:"image" yaml:"image"`
+	ImagePullSecrets []string             `json:"imagePullSecrets,omitempty" yaml:"imagePullSecrets,omitempty"`
+	Command          Command              `json:"command,omitempty" yaml:"command,omitempty"`
+	Args             []string             `json:"args,omitempty" yaml:"args,omitempty"`
+	Capabilities     Capabilities         `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	Resources        ResourceRequirements `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Containers       []ContainerSwap      `json:"containers,omitempty" yaml:"containers,omitempty"`
+	WorkDir          string               `json:"workdir,omitempty" yaml:"workdir,omitempty"`
+	Namespace        string               `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// UnmarshalYAML accepts both the name of the deployment as a shorthand and the structured deployment
+func (d *Deployment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*d = Deployment{Name: name}
+		return nil
+	}
+
+	type deployment Deployment
+	var structured deployment
+	if err := unmarshal(&structured); err != nil {
+		return err
+	}
+
+	*d = Deployment(structured)
+	return nil
+}
+
+// UnmarshalJSON accepts both the name of the deployment as a shorthand and the structured deployment
+func (d *Deployment) UnmarshalJSON(b []byte) error {
+	return d.UnmarshalYAML(func(v interface{}) error {
+		return json.Unmarshal(b, v)
+	})
 }
 
 //Mount represents how the local filesystem is mounted
 type Mount struct {
-	Source string `yaml:"source"`
-	Target string `yaml:"target"`
+	Source  string `json:"source" yaml:"source"`
+	Target  string `json:"target" yaml:"target"`
+	Enabled *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// ReadOnly mounts the target readonly in the container, and only sends the local changes
+	ReadOnly bool `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+
+	// Ignore are the file patterns that must not be synchronized, relative to the source of the mount. They're not applied yet
+	Ignore []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+
+	// RemoteSource syncs the mount from a git ref instead of a local folder. It can't be used with Source
+	RemoteSource *RemoteSource `json:"remoteSource,omitempty" yaml:"remoteSource,omitempty"`
+}
+
+// RemoteSource is the git repository, ref and path synched by a mount instead of a local folder
+type RemoteSource struct {
+	Repo string `json:"repo" yaml:"repo"`
+	Ref  string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
 }
 
+//Sync represents how the file synchronization behaves
+type Sync struct {
+	IdleThreshold time.Duration `json:"idleThreshold,omitempty" yaml:"idleThreshold,omitempty"`
+	OwnerUID      *int64        `json:"ownerUID,omitempty" yaml:"ownerUID,omitempty"`
+	OwnerGID      *int64        `json:"ownerGID,omitempty" yaml:"ownerGID,omitempty"`
+	Mode          string        `json:"mode,omitempty" yaml:"mode,omitempty"`
+}
+
+// ValidateOptions controls the optional checks run when validating a dev
+type ValidateOptions struct {
+	// AllowSpecialFilesystems skips the pseudo and network filesystem checks on the mount source
+	AllowSpecialFilesystems bool
+
+	// WarnImplicitEntrypoint makes Consistency to warn about swapped images without a command or args,
+	// since they rely on the entrypoint of the image, and minimal images may not have one
+	WarnImplicitEntrypoint bool
+
+	// AbsoluteSourcesOnly rejects the relative mount sources instead of resolving them, e.g. in CI,
+	// so the synched folders don't depend on the working directory or the location of the manifest
+	AbsoluteSourcesOnly bool
+}
+
+// Validation holds the options used when validating a dev
+var Validation = ValidateOptions{}
+
 //NewDev returns a new instance of dev with default values
 func NewDev() *Dev {
 	return &Dev{
 		Swap: Swap{
 			Deployment: Deployment{},
 		},
-		Mount: Mount{
-			Source: ".",
-			Target: "/app",
+		Mounts: []Mount{
+			{
+				Source: ".",
+				Target: defaultMountTarget(),
+			},
 		},
 		Scripts: make(map[string]string),
 	}
 }
 
+// validate runs every check of the dev, including that the mount source folders exist and are readable
 func (dev *Dev) validate() error {
-	file, err := os.Stat(dev.Mount.Source)
-	if err != nil && os.IsNotExist(err) {
-		return fmt.Errorf("Source mount folder %s does not exists", dev.Mount.Source)
+	return dev.validateWith(true)
+}
+
+// ValidateStructure checks the dev like ReadDev does, except for the mount source folders on the local filesystem,
+// e.g. to validate a manifest in a CI pipeline without the source code
+func (dev *Dev) ValidateStructure() error {
+	return dev.validateWith(false)
+}
+
+func (dev *Dev) validateWith(checkSources bool) error {
+	var errs []*FieldError
+	errs = append(errs, dev.validateMounts(checkSources)...)
+	errs = append(errs, dev.validateAbsoluteSources()...)
+	errs = append(errs, dev.validateSourcesOutsideHome()...)
+
+	errs = append(errs, dev.validateSelector()...)
+	errs = append(errs, dev.validateNamespace()...)
+	errs = append(errs, dev.validateContainerName()...)
+	errs = append(errs, dev.validateImagePullSecrets()...)
+
+	// an empty image keeps the image of the swapped container
+	if dev.Swap.Deployment.Image != "" {
+		if err := validateImage(dev.Swap.Deployment.Image); err != nil {
+			errs = append(errs, dev.fieldErrorf("swap.deployment.image", "%s", err))
+		}
 	}
-	if !file.Mode().IsDir() {
-		return fmt.Errorf("Source mount folder is not a directory")
+
+	if dev.SyncImage != "" {
+		if err := validateImage(dev.SyncImage); err != nil {
+			errs = append(errs, dev.fieldErrorf("syncImage", "%s", err))
+		}
+	}
+
+	if dev.SyncVolumeSize != "" {
+		if size, err := resource.ParseQuantity(dev.SyncVolumeSize); err != nil {
+			errs = append(errs, dev.fieldErrorf("syncVolumeSize", "Sync volume size '%s' is not a valid quantity, e.g. 10Gi", dev.SyncVolumeSize))
+		} else if size.Sign() <= 0 {
+			errs = append(errs, dev.fieldErrorf("syncVolumeSize", "Sync volume size must be greater than zero, got '%s'", dev.SyncVolumeSize))
+		}
+	}
+
+	errs = append(errs, dev.validateEmptyElements("swap.deployment.command", "Swap deployment command", dev.Swap.Deployment.Command)...)
+	errs = append(errs, dev.validateEmptyElements("swap.deployment.args", "Swap deployment args", dev.Swap.Deployment.Args)...)
+
+	if err := dev.Swap.Deployment.Capabilities.validate(); err != nil {
+		errs = append(errs, dev.fieldErrorf("swap.deployment.capabilities", "%s", err))
+	}
+
+	if err := dev.Swap.Deployment.Resources.validate(); err != nil {
+		errs = append(errs, dev.fieldErrorf("swap.deployment.resources", "%s", err))
 	}
 
-	if dev.Swap.Deployment.Name == "" {
-		return fmt.Errorf("Swap deployment name cannot be empty")
+	if dev.Swap.Deployment.WorkDir != "" && !path.IsAbs(dev.Swap.Deployment.WorkDir) {
+		errs = append(errs, dev.fieldErrorf("swap.deployment.workdir", "Swap deployment workdir must be an absolute path, got %q", dev.Swap.Deployment.WorkDir))
+	}
+
+	errs = append(errs, dev.validateContainers()...)
+	errs = append(errs, dev.validateEnvironment()...)
+	errs = append(errs, dev.validatePorts()...)
+	errs = append(errs, dev.validateIgnore()...)
+	errs = append(errs, dev.validateMountIgnore()...)
+	errs = append(errs, dev.validateLifecycle()...)
+	errs = append(errs, dev.validateDown()...)
+	errs = append(errs, dev.validateHealthcheck()...)
+	errs = append(errs, dev.validateScripts()...)
+	errs = append(errs, dev.validateControlCharacters()...)
+
+	if dev.Sync.IdleThreshold < 0 {
+		errs = append(errs, dev.fieldErrorf("sync.idleThreshold", "Sync idle threshold must be positive, got %s", dev.Sync.IdleThreshold))
+	}
+
+	if dev.Editor != "" && strings.TrimSpace(dev.Editor) == "" {
+		errs = append(errs, dev.fieldErrorf("editor", "Editor cannot be blank"))
+	}
+
+	switch dev.Sync.Mode {
+	case "", SyncModeTwoWay, SyncModeSendOnly, SyncModeReceiveOnly:
+	default:
+		errs = append(errs, dev.fieldErrorf("sync.mode", "Sync mode '%s' is not valid, it must be %s, %s or %s", dev.Sync.Mode, SyncModeTwoWay, SyncModeSendOnly, SyncModeReceiveOnly))
+	}
+
+	if dev.Sync.OwnerUID != nil && *dev.Sync.OwnerUID < 0 {
+		errs = append(errs, dev.fieldErrorf("sync.ownerUID", "Sync owner UID cannot be negative, got %d", *dev.Sync.OwnerUID))
+	}
+
+	if dev.Sync.OwnerGID != nil && *dev.Sync.OwnerGID < 0 {
+		errs = append(errs, dev.fieldErrorf("sync.ownerGID", "Sync owner GID cannot be negative, got %d", *dev.Sync.OwnerGID))
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
 	}
 
 	return nil
 }
 
-//ReadDev returns a Dev object from a given file
+//ReadDev returns a Dev object from a given file. Files with a .json extension are decoded as json
 func ReadDev(devPath string) (*Dev, error) {
-	b, err := ioutil.ReadFile(devPath)
+	return ReadDevContext(context.Background(), devPath)
+}
+
+// ReadDevContext is like ReadDev, but returns the context error if it's done before the file is read and validated,
+// e.g. when a network filesystem hangs
+func ReadDevContext(ctx context.Context, devPath string) (*Dev, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if ctx.Done() == nil {
+		return readDevFile(devPath, false)
+	}
+
+	type result struct {
+		dev *Dev
+		err error
+	}
+
+	// a hanging read or stat can't be interrupted, so it's left behind in its goroutine
+	c := make(chan result, 1)
+	go func() {
+		d, err := readDevFile(devPath, false)
+		c <- result{dev: d, err: err}
+	}()
+
+	select {
+	case r := <-c:
+		return r.dev, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ReadDevCreate is like ReadDev, but creates the missing source folders of the enabled mounts instead of rejecting them,
+// e.g. to read a manifest generated before its source code. The relative sources are created next to the manifest
+func ReadDevCreate(devPath string) (*Dev, error) {
+	return readDevFile(devPath, true)
+}
+
+// readDevFile reads and validates the manifest. If createSources is set, the sources are checked once the missing ones
+// are created, after fixPath resolves them against the manifest folder
+func readDevFile(devPath string, createSources bool) (*Dev, error) {
+	f, err := openManifest(devPath)
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	d, err := loadDev(b)
+	d, err := readDev(f, filepath.Dir(devPath), strings.EqualFold(filepath.Ext(devPath), ".json"), !createSources)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := d.validate(); err != nil {
+	if err := d.resolveFiles(devPath, !createSources); err != nil {
+		return nil, err
+	}
+
+	if createSources {
+		if err := d.createMissingSources(); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.manifestPath, err = filepath.Abs(devPath); err != nil {
 		return nil, err
 	}
 
-	d.fixPath(devPath)
 	return d, nil
 }
 
-func loadDev(b []byte) (*Dev, error) {
-	dev := Dev{
-		Mount: Mount{
-			Source: ".",
-			Target: "/src",
-		},
+// ManifestPath returns the absolute path of the manifest file the dev was read from, or an empty string
+// if it wasn't read from a file
+func (dev *Dev) ManifestPath() string {
+	return dev.manifestPath
+}
+
+// resolveFiles loads the ignore file next to the manifest and resolves the mount sources against its path.
+// If checkSources is set, the resolved source folders are checked on the local filesystem
+func (dev *Dev) resolveFiles(devPath string, checkSources bool) error {
+	if err := dev.loadIgnoreFile(filepath.Dir(devPath)); err != nil {
+		return fmt.Errorf("error reading %s: %s", CNDIgnoreFile, err)
+	}
+
+	dev.fixPath(devPath)
+	errs := dev.validateSourcesOutsideHome()
+	if checkSources {
+		errs = append(errs, dev.validateSources()...)
 	}
 
-	err := yaml.Unmarshal(b, &dev)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// Validate reads and validates a manifest file. Its relative mount sources are checked against the folder of the manifest
+func Validate(devPath string) error {
+	f, err := openManifest(devPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d, err := readDev(f, filepath.Dir(devPath), strings.EqualFold(filepath.Ext(devPath), ".json"), true)
+	if err != nil {
+		return err
+	}
+
+	return d.resolveFiles(devPath, true)
+}
+
+// ReadDevFrom returns a Dev object from a yaml or json manifest. Since there is no manifest file,
+// relative mount sources and script files are resolved against the current working directory
+func ReadDevFrom(r io.Reader) (*Dev, error) {
+	d, err := readDev(r, "", false, true)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.HasPrefix(dev.Mount.Source, "~/") {
-		home := os.Getenv("HOME")
-		dev.Mount.Source = filepath.Join(home, dev.Mount.Source[2:])
+	d.fixPath("")
+	errs := append(d.validateSourcesOutsideHome(), d.validateSources()...)
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	return d, nil
+}
+
+func readDev(r io.Reader, dir string, asJSON, checkSources bool) (*Dev, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := decodeDev(b, asJSON || isJSONManifest(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.resolve(dir); err != nil {
+		return nil, err
+	}
+
+	if err := d.validateWith(checkSources); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// resolveAndValidate resolves the git root sources and the script files against dir, and validates the dev
+func (dev *Dev) resolveAndValidate(dir string) error {
+	if err := dev.resolve(dir); err != nil {
+		return err
+	}
+
+	return dev.validate()
+}
+
+// resolve resolves the git root sources, the includes, the user defaults and the script files against dir
+func (dev *Dev) resolve(dir string) error {
+	if err := dev.resolveGitRootSources(dir); err != nil {
+		return invalidManifest(err)
+	}
+
+	if err := dev.resolveIncludes(dir); err != nil {
+		return invalidManifest(err)
+	}
+
+	if err := dev.resolveUserDefaults(); err != nil {
+		return invalidManifest(err)
+	}
+
+	if err := dev.resolveScriptFiles(dir); err != nil {
+		return invalidManifest(err)
+	}
+
+	return nil
+}
+
+// WriteDev writes the dev as a yaml manifest to the given file, creating its parent folders if needed.
+// yaml sorts the keys of the maps, e.g. the scripts, so the same dev is always written the same way
+func WriteDev(dev *Dev, devPath string) error {
+	b, err := yaml.Marshal(dev)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(devPath), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(devPath, b, 0644)
+}
+
+// manifest is the yaml representation of a dev, that also accepts the deprecated singular mount
+type manifest struct {
+	Dev   `yaml:",inline"`
+	Mount *Mount `json:"mount,omitempty" yaml:"mount,omitempty"`
+}
+
+// LoadDev decodes a yaml or json manifest and applies its defaults, like the default mount and the home folder expansion.
+// The dev is neither validated nor are its paths resolved against a folder, that's left to the caller
+func LoadDev(b []byte) (*Dev, error) {
+	return decodeDev(b, isJSONManifest(b))
+}
+
+// isEmptyManifest returns true if the yaml manifest has no content other than whitespace and comments
+func isEmptyManifest(b []byte) bool {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return true
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return false
+	}
+
+	return doc == nil
+}
+
+// isJSONManifest returns true if the manifest content is a json object
+func isJSONManifest(b []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(b)), "{")
+}
+
+func decodeDev(b []byte, asJSON bool) (*Dev, error) {
+	if !asJSON && isEmptyManifest(b) {
+		return nil, invalidManifest(ErrEmptyManifest)
+	}
+
+	decoded, scriptFiles, err := extractScriptFiles(b, asJSON)
+	if err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	var m manifest
+	if asJSON {
+		err = json.Unmarshal(decoded, &m)
+	} else {
+		d := yaml.NewDecoder(bytes.NewReader(decoded))
+		d.SetStrict(true)
+		if err = d.Decode(&m); err == io.EOF {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	dev := m.Dev
+	dev.scriptFiles = scriptFiles
+	if dev.APIVersion == "" {
+		dev.APIVersion = CurrentAPIVersion
+	}
+
+	if err := validateAPIVersion(dev.APIVersion); err != nil {
+		return nil, invalidManifest(err)
+	}
+	if m.Mount != nil {
+		if len(dev.Mounts) > 0 {
+			return nil, invalidManifest(fmt.Errorf("'mount' and 'mounts' cannot be used together"))
+		}
+
+		dev.Mounts = []Mount{*m.Mount}
+	}
+
+	if len(dev.Mounts) == 0 {
+		dev.Mounts = []Mount{{}}
+	}
+
+	if asJSON {
+		// json manifests have no line positions, but the used fields are still tracked
+		dev.positions = map[string]position{}
+		if m.Mount != nil {
+			dev.positions["mount"] = position{}
+		}
+	} else {
+		dev.positions = getFieldPositions(b)
+	}
+	dev.deprecations = getDeprecations(dev.positions)
+
+	if err := dev.expandEnvFields(); err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	// the defaults only apply to the fields absent from the manifest, the explicitly empty ones are rejected by validate
+	present := presentMountFields(decoded, asJSON)
+	for i := range dev.Mounts {
+		var p mountPresence
+		if i < len(present) {
+			p = present[i]
+		}
+
+		if p.Source == nil && dev.Mounts[i].RemoteSource == nil {
+			dev.Mounts[i].Source = "."
+		}
+
+		if p.Target == nil {
+			dev.Mounts[i].Target = defaultMountTarget()
+		}
+
+		if dev.Mounts[i].Source, err = expandHome(dev.Mounts[i].Source); err != nil {
+			return nil, invalidManifest(err)
+		}
+
+		if dev.Mounts[i].Target, err = expandHome(dev.Mounts[i].Target); err != nil {
+			return nil, invalidManifest(err)
+		}
+
+		dev.Mounts[i].Target = cleanTarget(dev.Mounts[i].Target)
+	}
+
+	for i := range dev.Swap.Deployment.Containers {
+		dev.Swap.Deployment.Containers[i].Target = cleanTarget(dev.Swap.Deployment.Containers[i].Target)
+	}
+
+	if dev.Swap.Deployment.WorkDir == "" {
+		dev.Swap.Deployment.WorkDir = dev.MainMount().Target
 	}
 
 	return &dev, nil
 }
 
+// expandHome replaces a leading ~/ with the home folder of the user. It fails if the home folder can't be determined,
+// e.g. when $HOME is unset in a minimal container
+func expandHome(p string) (string, error) {
+	if !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("'%s' is relative to the home folder, but it can't be determined: %s", p, err)
+	}
+
+	return filepath.Join(home, p[2:]), nil
+}
+
+// fixPath makes the relative mount sources absolute, relative to the folder of the manifest.
+// Symlinks are resolved, e.g. /tmp in macOS, so the sources match the paths resolved by syncthing
 func (dev *Dev) fixPath(originalPath string) {
 	wd, _ := os.Getwd()
 
-	if !filepath.IsAbs(dev.Mount.Source) {
-		if filepath.IsAbs(originalPath) {
-			dev.Mount.Source = path.Join(path.Dir(originalPath), dev.Mount.Source)
-		} else {
+	for i := range dev.Mounts {
+		if dev.Mounts[i].IsRemote() {
+			continue
+		}
+
+		if !filepath.IsAbs(dev.Mounts[i].Source) {
+			if filepath.IsAbs(originalPath) {
+				dev.Mounts[i].Source = path.Join(path.Dir(originalPath), dev.Mounts[i].Source)
+			} else {
+
+				dev.Mounts[i].Source = path.Join(wd, path.Dir(originalPath), dev.Mounts[i].Source)
+			}
+
+			dev.Mounts[i].Source = evalSymlinks(dev.Mounts[i].Source)
+		}
+	}
+}
+
+// evalSymlinks returns the canonical path of p, or p if it can't be resolved, e.g. because it doesn't exist yet
+func evalSymlinks(p string) string {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return p
+	}
+
+	return resolved
+}
+
+// GetSyncImage returns the image of the container running syncthing, DefaultSyncImage by default
+func (dev *Dev) GetSyncImage() string {
+	if dev.SyncImage != "" {
+		return dev.SyncImage
+	}
+
+	return DefaultSyncImage
+}
+
+// GetInitSyncImage returns the image of the init container preparing the synched volume, DefaultInitSyncImage by default
+func (dev *Dev) GetInitSyncImage() string {
+	if dev.initSyncImage != "" {
+		return dev.initSyncImage
+	}
+
+	return DefaultInitSyncImage
+}
+
+// GetEditor returns the editor command used by interactive scripts, defaulting to $EDITOR
+func (dev *Dev) GetEditor() string {
+	if dev.Editor != "" {
+		return dev.Editor
+	}
+
+	return os.Getenv("EDITOR")
+}
+
+// UnmarshalJSON decodes the sync of a json manifest, where the idle threshold is a duration string like in yaml
+func (s *Sync) UnmarshalJSON(b []byte) error {
+	type sync Sync
+	var raw struct {
+		sync
+		IdleThreshold interface{} `json:"idleThreshold,omitempty"`
+	}
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*s = Sync(raw.sync)
+	switch v := raw.IdleThreshold.(type) {
+	case nil:
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		s.IdleThreshold = d
+	case float64:
+		s.IdleThreshold = time.Duration(v)
+	default:
+		return fmt.Errorf("'%v' is not a valid idle threshold", v)
+	}
+
+	return nil
+}
+
+// GetIdleThreshold returns how long the synched files must stay unchanged to consider the sync idle
+func (s Sync) GetIdleThreshold() time.Duration {
+	if s.IdleThreshold == 0 {
+		return DefaultSyncIdleThreshold
+	}
+
+	return s.IdleThreshold
+}
+
+// GetMode returns the direction of the synchronization, SyncModeTwoWay by default
+func (s Sync) GetMode() string {
+	if s.Mode == "" {
+		return SyncModeTwoWay
+	}
+
+	return s.Mode
+}
+
+// Owner returns the chown owner of the synched files, e.g. 1000:1000, or an empty string if the ownership is not changed
+func (s Sync) Owner() string {
+	owner := ""
+	if s.OwnerUID != nil {
+		owner = fmt.Sprintf("%d", *s.OwnerUID)
+	}
 
-			dev.Mount.Source = path.Join(wd, path.Dir(originalPath), dev.Mount.Source)
+	if s.OwnerGID != nil {
+		owner = fmt.Sprintf("%s:%d", owner, *s.OwnerGID)
+	}
+
+	return owner
+}
+
+// SnapshotScripts returns a copy of the scripts, independent of the dev
+func (dev *Dev) SnapshotScripts() map[string]string {
+	snapshot := make(map[string]string, len(dev.Scripts))
+	for k, v := range dev.Scripts {
+		snapshot[k] = v
+	}
+
+	return snapshot
+}
+
+// RestoreScripts replaces the scripts with a snapshot returned by SnapshotScripts
+func (dev *Dev) RestoreScripts(snapshot map[string]string) {
+	dev.Scripts = make(map[string]string, len(snapshot))
+	for k, v := range snapshot {
+		dev.Scripts[k] = v
+	}
+}
+
+// Equal returns true if both devs have the same configuration. Nil and empty slices and maps are equal
+func (dev *Dev) Equal(other *Dev) bool {
+	if dev == nil || other == nil {
+		return dev == other
+	}
+
+	return reflect.DeepEqual(dev.normalized(), other.normalized())
+}
+
+// String returns a single line summary of the dev for logs, e.g.
+// deployment=api container=app image=okteto/api mounts=/home/user/api->/app
+func (dev *Dev) String() string {
+	if dev == nil {
+		return "<nil>"
+	}
+
+	mounts := make([]string, len(dev.Mounts))
+	for i, m := range dev.Mounts {
+		mounts[i] = fmt.Sprintf("%s->%s", m.Source, m.Target)
+	}
+
+	return fmt.Sprintf(
+		"deployment=%s container=%s image=%s mounts=%s",
+		dev.Swap.Deployment.Name,
+		dev.Swap.Deployment.Container,
+		dev.Swap.Deployment.Image,
+		strings.Join(mounts, ","),
+	)
+}
+
+// SameTarget returns true if both devs swap the same deployment container of the same namespace. The devs selecting the deployment by its labels
+// are compared by their selectors, since the name is only known once ResolveSelector runs
+func (dev *Dev) SameTarget(other *Dev) bool {
+	return dev.Swap.Deployment.Namespace == other.Swap.Deployment.Namespace &&
+		dev.Swap.Deployment.Name == other.Swap.Deployment.Name &&
+		dev.selectorString() == other.selectorString() &&
+		dev.Swap.Deployment.Container == other.Swap.Deployment.Container
+}
+
+// targetString returns the deployment swapped by the dev for messages, its name or its selector
+func (dev *Dev) targetString() string {
+	if dev.Swap.Deployment.Name == "" && len(dev.Swap.Selector) > 0 {
+		return dev.selectorString()
+	}
+
+	return dev.Swap.Deployment.Name
+}
+
+// ValidateEnvironments checks that no two named environments swap the same deployment container
+func ValidateEnvironments(devs map[string]*Dev) error {
+	names := make([]string, 0, len(devs))
+	for name := range devs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := range names {
+		for _, other := range names[i+1:] {
+			if devs[names[i]].SameTarget(devs[other]) {
+				return fmt.Errorf("environments '%s' and '%s' swap the same deployment '%s'", names[i], other, devs[other].targetString())
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeepCopy returns a copy of the dev that shares no slices, maps or pointers with it
+func (dev *Dev) DeepCopy() *Dev {
+	d := *dev
+	d.Swap.Deployment.Command = copyStrings(dev.Swap.Deployment.Command)
+	d.Swap.Deployment.Args = copyStrings(dev.Swap.Deployment.Args)
+	d.Swap.Selector = copyStringMap(dev.Swap.Selector)
+	d.Swap.Deployment.ImagePullSecrets = copyStrings(dev.Swap.Deployment.ImagePullSecrets)
+	d.Swap.Deployment.Capabilities.Add = copyStrings(dev.Swap.Deployment.Capabilities.Add)
+	d.Swap.Deployment.Capabilities.Drop = copyStrings(dev.Swap.Deployment.Capabilities.Drop)
+	d.Swap.Deployment.Resources.Requests = copyStringMap(dev.Swap.Deployment.Resources.Requests)
+	d.Swap.Deployment.Resources.Limits = copyStringMap(dev.Swap.Deployment.Resources.Limits)
+	if dev.Swap.Deployment.Containers != nil {
+		d.Swap.Deployment.Containers = make([]ContainerSwap, len(dev.Swap.Deployment.Containers))
+		for i, c := range dev.Swap.Deployment.Containers {
+			d.Swap.Deployment.Containers[i] = c
+			d.Swap.Deployment.Containers[i].Command = copyStrings(c.Command)
+			d.Swap.Deployment.Containers[i].Args = copyStrings(c.Args)
+		}
+	}
+
+	if dev.Mounts != nil {
+		d.Mounts = make([]Mount, len(dev.Mounts))
+		for i, m := range dev.Mounts {
+			d.Mounts[i] = m
+			d.Mounts[i].Ignore = copyStrings(m.Ignore)
+			if m.RemoteSource != nil {
+				remote := *m.RemoteSource
+				d.Mounts[i].RemoteSource = &remote
+			}
+			if m.Enabled != nil {
+				enabled := *m.Enabled
+				d.Mounts[i].Enabled = &enabled
+			}
+		}
+	}
+
+	d.Sync.OwnerUID = copyInt64(dev.Sync.OwnerUID)
+	d.Sync.OwnerGID = copyInt64(dev.Sync.OwnerGID)
+	d.Scripts = copyStringMap(dev.Scripts)
+	d.Ports = copyStrings(dev.Ports)
+	d.Ignore = copyStrings(dev.Ignore)
+	d.Include = copyStrings(dev.Include)
+	d.Lifecycle.PostStart = copyStrings(dev.Lifecycle.PostStart)
+	d.Lifecycle.PreStop = copyStrings(dev.Lifecycle.PreStop)
+	d.Down = copyStrings(dev.Down)
+	if dev.Healthcheck != nil {
+		h := *dev.Healthcheck
+		h.Command = copyStrings(h.Command)
+		d.Healthcheck = &h
+	}
+
+	if dev.Environment != nil {
+		d.Environment = append([]EnvVar{}, dev.Environment...)
+	}
+
+	if dev.positions != nil {
+		d.positions = make(map[string]position, len(dev.positions))
+		for k, v := range dev.positions {
+			d.positions[k] = v
 		}
 	}
+
+	if dev.deprecations != nil {
+		d.deprecations = append([]Deprecation{}, dev.deprecations...)
+	}
+
+	d.scriptFiles = copyStringMap(dev.scriptFiles)
+	return &d
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	return append([]string{}, s...)
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+
+	return result
+}
+
+func copyInt64(i *int64) *int64 {
+	if i == nil {
+		return nil
+	}
+
+	v := *i
+	return &v
 }
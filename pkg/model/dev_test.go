@@ -1,10 +1,17 @@
 package model
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_fixPath(t *testing.T) {
@@ -41,20 +48,57 @@ func Test_fixPath(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dev := Dev{
-				Mount: Mount{
+				Mounts: []Mount{{
 					Source: tt.source,
 					Target: tt.target,
-				},
+				}},
 			}
 
 			dev.fixPath(tt.devPath)
-			if dev.Mount.Source != tt.expected {
-				t.Errorf("%s != %s", dev.Mount.Source, tt.expected)
+			if dev.Mounts[0].Source != tt.expected {
+				t.Errorf("%s != %s", dev.Mounts[0].Source, tt.expected)
 			}
 		})
 	}
 }
 
+func Test_fixPathSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "cnd-fixpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	real := filepath.Join(dir, "real")
+	if err := os.MkdirAll(filepath.Join(real, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := filepath.EvalSymlinks(filepath.Join(real, "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev := Dev{Mounts: []Mount{{Source: "src", Target: "/app"}, {Source: "missing", Target: "/missing"}}}
+	dev.fixPath(filepath.Join(link, "cnd.yml"))
+	if dev.Mounts[0].Source != expected {
+		t.Errorf("%s != %s", dev.Mounts[0].Source, expected)
+	}
+
+	if missing := filepath.Join(link, "missing"); dev.Mounts[1].Source != missing {
+		t.Errorf("%s != %s", dev.Mounts[1].Source, missing)
+	}
+}
+
 func Test_loadDev(t *testing.T) {
 	manifest := []byte(`
 swap:
@@ -67,7 +111,7 @@ swap:
 mount:
   source: /Users/example/app
   target: /app`)
-	d, err := loadDev(manifest)
+	d, err := LoadDev(manifest)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -85,6 +129,89 @@ mount:
 	}
 }
 
+func Test_LoadDevWithoutValidation(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: ~/missing-folder`))
+	if err != nil {
+		t.Fatalf("the manifest was validated: %s", err)
+	}
+
+	home := os.Getenv("HOME")
+	if d.Mounts[0].Source != filepath.Join(home, "missing-folder") || d.Mounts[0].Target != "/src" {
+		t.Errorf("defaults were not applied: %+v", d.Mounts[0])
+	}
+
+	if err := d.validate(); err == nil {
+		t.Error("missing source was accepted")
+	}
+}
+
+func Test_loadDevUnknownField(t *testing.T) {
+	manifest := []byte(`
+swap:
+  deployment:
+    name: deployment
+    comand: ["uwsgi"]`)
+	_, err := LoadDev(manifest)
+	if err == nil {
+		t.Fatal("misspelled field was accepted")
+	}
+
+	if !strings.Contains(err.Error(), "comand") {
+		t.Errorf("error doesn't name the misspelled field: %s", err)
+	}
+
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Mounts) != 1 || d.Mounts[0].Target != "/src" {
+		t.Errorf("default mount was not injected: %+v", d.Mounts)
+	}
+}
+
+func Test_loadDevDeploymentShorthand(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+	}{
+		{name: "shorthand", manifest: "swap:\n  deployment: my-app"},
+		{name: "structured", manifest: "swap:\n  deployment:\n    name: my-app"},
+		{name: "shorthand-json", manifest: `{"swap": {"deployment": "my-app"}}`},
+		{name: "structured-json", manifest: `{"swap": {"deployment": {"name": "my-app"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Swap.Deployment.Name != "my-app" {
+				t.Errorf("%s != my-app", d.Swap.Deployment.Name)
+			}
+		})
+	}
+
+	d, err := LoadDev([]byte("swap:\n  deployment: \"\""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.ValidateStructure(); err == nil || !strings.Contains(err.Error(), "swap.deployment.name") {
+		t.Errorf("empty shorthand deployment was accepted: %v", err)
+	}
+}
+
 func Test_loadDevDefaults(t *testing.T) {
 	var tests = []struct {
 		name     string
@@ -123,7 +250,7 @@ func Test_loadDevDefaults(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			d, err := loadDev(tt.manifest)
+			d, err := LoadDev(tt.manifest)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -143,3 +270,746 @@ func Test_loadDevDefaults(t *testing.T) {
 	}
 
 }
+
+func Test_loadDevWorkDir(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected string
+		valid    bool
+	}{
+		{
+			name: "default",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - target: /app`,
+			expected: "/app",
+			valid:    true,
+		},
+		{
+			name: "explicit",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    workdir: /app/src
+mounts:
+  - target: /app`,
+			expected: "/app/src",
+			valid:    true,
+		},
+		{
+			name: "relative",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    workdir: src`,
+			expected: "src",
+			valid:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Swap.Deployment.WorkDir != tt.expected {
+				t.Errorf("%s != %s", d.Swap.Deployment.WorkDir, tt.expected)
+			}
+
+			d.Mounts[0].Enabled = new(bool)
+			err = d.validate()
+			if tt.valid && err != nil {
+				t.Errorf("valid workdir was rejected: %s", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Error("relative workdir was accepted")
+			}
+		})
+	}
+}
+
+func Test_validateSyncImage(t *testing.T) {
+	var tests = []struct {
+		name  string
+		image string
+		valid bool
+	}{
+		{name: "empty", image: "", valid: true},
+		{name: "registry", image: "registry.local:5000/okteto/syncthing:1.0", valid: true},
+		{name: "invalid", image: "Okteto/Syncthing", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled := false
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment"}}, Mounts: []Mount{{Enabled: &enabled}}, SyncImage: tt.image}
+			err := dev.validate()
+			if tt.valid && err != nil {
+				t.Errorf("valid sync image was rejected: %s", err)
+			}
+
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "syncImage")) {
+				t.Errorf("invalid sync image was accepted: %v", err)
+			}
+		})
+	}
+}
+
+func Test_ValidateEnvironments(t *testing.T) {
+	newDev := func(name, container string) *Dev {
+		return &Dev{Swap: Swap{Deployment: Deployment{Name: name, Container: container}}}
+	}
+
+	if err := ValidateEnvironments(map[string]*Dev{
+		"api":    newDev("api", "app"),
+		"worker": newDev("api", "worker"),
+		"web":    newDev("web", "app"),
+	}); err != nil {
+		t.Errorf("distinct environments were rejected: %s", err)
+	}
+
+	err := ValidateEnvironments(map[string]*Dev{
+		"api":  newDev("api", "app"),
+		"api2": newDev("api", "app"),
+	})
+	if err == nil {
+		t.Fatal("colliding environments were accepted")
+	}
+
+	if !strings.Contains(err.Error(), "'api'") || !strings.Contains(err.Error(), "'api2'") {
+		t.Errorf("error doesn't name both environments: %s", err)
+	}
+}
+
+func Test_loadDevIdleThreshold(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+sync:
+  idleThreshold: 10s`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Sync.GetIdleThreshold() != 10*time.Second {
+		t.Errorf("idle threshold was not parsed: %s", d.Sync.IdleThreshold)
+	}
+
+	d, err = LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Sync.GetIdleThreshold() != DefaultSyncIdleThreshold {
+		t.Errorf("default idle threshold was not applied: %s", d.Sync.GetIdleThreshold())
+	}
+}
+
+func Test_loadDevSyncMode(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected string
+		err      string
+	}{
+		{name: "default", manifest: "swap:\n  deployment:\n    name: deployment", expected: SyncModeTwoWay},
+		{name: "send-only", manifest: "swap:\n  deployment:\n    name: deployment\nsync:\n  mode: send-only", expected: SyncModeSendOnly},
+		{name: "json", manifest: `{"swap": {"deployment": {"name": "deployment"}}, "sync": {"mode": "receive-only"}}`, expected: SyncModeReceiveOnly},
+		{name: "invalid", manifest: "swap:\n  deployment:\n    name: deployment\nsync:\n  mode: one-way", err: "Sync mode 'one-way' is not valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.ValidateStructure()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("wrong error, expected '%s': %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Sync.GetMode() != tt.expected {
+				t.Errorf("%s != %s", d.Sync.GetMode(), tt.expected)
+			}
+		})
+	}
+}
+
+func Test_validateEmptyCommandElements(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest []byte
+		expected string
+	}{
+		{
+			"empty-command",
+			[]byte(`
+swap:
+  deployment:
+    name: deployment
+    command:
+      - uwsgi
+      -
+mount:
+  source: .`),
+			"element 1",
+		},
+		{
+			"empty-args",
+			[]byte(`
+swap:
+  deployment:
+    name: deployment
+    args: ["", "--gevent"]
+mount:
+  source: .`),
+			"element 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev(tt.manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.validate()
+			if err == nil {
+				t.Fatal("empty element was accepted")
+			}
+
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("error doesn't name the element: %s", err)
+			}
+		})
+	}
+}
+
+func Test_SyncOwner(t *testing.T) {
+	uid := int64(1000)
+	gid := int64(0)
+
+	var tests = []struct {
+		name     string
+		sync     Sync
+		expected string
+	}{
+		{name: "unset", sync: Sync{}, expected: ""},
+		{name: "uid", sync: Sync{OwnerUID: &uid}, expected: "1000"},
+		{name: "gid", sync: Sync{OwnerGID: &gid}, expected: ":0"},
+		{name: "both", sync: Sync{OwnerUID: &uid, OwnerGID: &gid}, expected: "1000:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if owner := tt.sync.Owner(); owner != tt.expected {
+				t.Errorf("%s != %s", owner, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_GetEditor(t *testing.T) {
+	os.Setenv("EDITOR", "vi")
+	defer os.Unsetenv("EDITOR")
+
+	dev := &Dev{}
+	if dev.GetEditor() != "vi" {
+		t.Errorf("editor didn't default to $EDITOR: %s", dev.GetEditor())
+	}
+
+	dev.Editor = "code --wait"
+	if dev.GetEditor() != "code --wait" {
+		t.Errorf("editor wasn't overridden: %s", dev.GetEditor())
+	}
+
+	dev = &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment"}}, Mounts: []Mount{{Source: ".", Target: "/app"}}, Editor: "  "}
+	if err := dev.validate(); err == nil {
+		t.Errorf("blank editor was accepted")
+	}
+}
+
+func Test_SnapshotScripts(t *testing.T) {
+	dev := &Dev{Scripts: map[string]string{"test": "make test"}}
+
+	snapshot := dev.SnapshotScripts()
+	dev.Scripts["lint"] = "make lint"
+	dev.Scripts["test"] = "go test"
+
+	if len(snapshot) != 1 || snapshot["test"] != "make test" {
+		t.Fatalf("snapshot is not independent: %+v", snapshot)
+	}
+
+	dev.RestoreScripts(snapshot)
+	if !reflect.DeepEqual(dev.Scripts, map[string]string{"test": "make test"}) {
+		t.Errorf("scripts were not restored: %+v", dev.Scripts)
+	}
+
+	dev.Scripts["build"] = "make"
+	if _, ok := snapshot["build"]; ok {
+		t.Errorf("restored scripts share the snapshot")
+	}
+}
+
+func Test_ValidateStructure(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: /does/not/exist
+    target: /app`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.ValidateStructure(); err != nil {
+		t.Errorf("missing source was checked: %s", err)
+	}
+
+	if err := d.validate(); err == nil {
+		t.Errorf("missing source was accepted by the full validation")
+	}
+
+	d.Mounts[0].Target = "app"
+	if err := d.ValidateStructure(); err == nil {
+		t.Errorf("relative target was accepted")
+	}
+}
+
+func Test_validateDisabledMount(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mount:
+  source: /does/not/exist
+  enabled: false`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.IsSynched() {
+		t.Fatal("mount is enabled")
+	}
+
+	if err := d.validate(); err != nil {
+		t.Errorf("missing source was checked with a disabled mount: %s", err)
+	}
+}
+
+func Test_WriteDev(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dev := NewDev()
+	dev.Swap.Deployment.Name = "deployment"
+	dev.Swap.Deployment.Image = "okteto/cnd"
+	dev.Mounts[0].Source = dir
+	dev.Scripts["test"] = "make test"
+
+	devPath := filepath.Join(dir, "manifests", "cnd.yml")
+	if err := WriteDev(dev, devPath); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{"container:", "command:", "args:"} {
+		if strings.Contains(string(b), field) {
+			t.Errorf("empty field %s was written:\n%s", field, string(b))
+		}
+	}
+
+	result, err := ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(dev.normalized(), result.normalized()) {
+		t.Errorf("%+v != %+v", dev, result)
+	}
+}
+
+func Test_WriteDevStableScripts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dev := NewDev()
+	dev.Swap.Deployment.Name = "deployment"
+	for _, name := range []string{"test", "build", "lint", "deploy", "clean", "run"} {
+		dev.Scripts[name] = "make " + name
+	}
+
+	var written [][]byte
+	for i := 0; i < 2; i++ {
+		devPath := filepath.Join(dir, fmt.Sprintf("cnd-%d.yml", i))
+		if err := WriteDev(dev, devPath); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(devPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		written = append(written, b)
+	}
+
+	if !bytes.Equal(written[0], written[1]) {
+		t.Errorf("the dev was written differently:\n%s\n%s", written[0], written[1])
+	}
+
+	expected := "scripts:\n  build: make build\n  clean: make clean\n  deploy: make deploy\n  lint: make lint\n  run: make run\n  test: make test\n"
+	if !strings.Contains(string(written[0]), expected) {
+		t.Errorf("the scripts weren't sorted:\n%s", written[0])
+	}
+}
+
+func Test_ReadDevContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	devPath := filepath.Join(dir, "cnd.yml")
+	manifest := []byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: .
+    target: /app`)
+	if err := ioutil.WriteFile(devPath, manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	d, err := ReadDevContext(ctx, devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Swap.Deployment.Name != "deployment" || d.Mounts[0].Source != dir {
+		t.Errorf("wrong dev: %+v", d)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadDevContext(cancelled, devPath); err != context.Canceled {
+		t.Errorf("cancelled context was ignored: %v", err)
+	}
+}
+
+func Test_ReadDevFrom(t *testing.T) {
+	wd, _ := os.Getwd()
+	wd, _ = filepath.EvalSymlinks(wd)
+
+	d, err := ReadDevFrom(strings.NewReader(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: .
+    target: /app`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Mounts[0].Source != wd {
+		t.Errorf("%s != %s", d.Mounts[0].Source, wd)
+	}
+
+	_, err = ReadDevFrom(strings.NewReader(`
+swap:
+  deployment:
+    name: ""`))
+	if err == nil {
+		t.Errorf("invalid manifest was accepted")
+	}
+}
+
+func Test_loadDevJSON(t *testing.T) {
+	yamlDev, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+    command: ["uwsgi"]
+mounts:
+  - target: /app
+sync:
+  idleThreshold: 10s
+environment:
+  - DEBUG=true`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonDev, err := LoadDev([]byte(`
+  {
+    "swap": {"deployment": {"name": "deployment", "command": ["uwsgi"]}},
+    "mounts": [{"target": "/app"}],
+    "sync": {"idleThreshold": "10s"},
+    "environment": ["DEBUG=true"]
+  }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(yamlDev.normalized(), jsonDev.normalized()) {
+		t.Errorf("%+v != %+v", yamlDev, jsonDev)
+	}
+
+	jsonDev, err = LoadDev([]byte(`{"swap": {"deployment": {"name": "deployment"}}, "mount": {"source": "src"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jsonDev.Mounts[0].Source != "src" || jsonDev.Mounts[0].Target != "/src" {
+		t.Errorf("defaults were not applied: %+v", jsonDev.Mounts)
+	}
+
+	if len(jsonDev.Deprecations()) != 1 {
+		t.Errorf("singular mount is not reported as deprecated: %+v", jsonDev.Deprecations())
+	}
+}
+
+func Test_ReadDevJSONExtension(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	devPath := filepath.Join(dir, "cnd.json")
+	if err := ioutil.WriteFile(devPath, []byte(`{"swap": {"deployment": {"name": "deployment"}}, "mounts": [{"source": ".", "target": "/app"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Mounts[0].Source != dir {
+		t.Errorf("%s != %s", d.Mounts[0].Source, dir)
+	}
+}
+
+func Test_String(t *testing.T) {
+	var tests = []struct {
+		name     string
+		dev      *Dev
+		expected string
+	}{
+		{
+			name: "full",
+			dev: &Dev{
+				Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", Image: "okteto/api"}},
+				Mounts: []Mount{{Source: "/home/user/api", Target: "/app"}, {Source: "/home/user/config", Target: "/config"}},
+			},
+			expected: "deployment=api container=app image=okteto/api mounts=/home/user/api->/app,/home/user/config->/config",
+		},
+		{
+			name:     "empty",
+			dev:      &Dev{},
+			expected: "deployment= container= image= mounts=",
+		},
+		{
+			name:     "nil",
+			expected: "<nil>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := tt.dev.String(); s != tt.expected {
+				t.Errorf("%s != %s", s, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_Equal(t *testing.T) {
+	base := func() *Dev {
+		return &Dev{
+			Swap:    Swap{Deployment: Deployment{Name: "deployment", Args: []string{"--debug", "--port"}}},
+			Mounts:  []Mount{{Source: ".", Target: "/app"}},
+			Scripts: map[string]string{"test": "make test"},
+		}
+	}
+
+	var nilDev *Dev
+	var tests = []struct {
+		name     string
+		a        *Dev
+		b        *Dev
+		expected bool
+	}{
+		{name: "same", a: base(), b: base(), expected: true},
+		{name: "empty-and-nil", a: &Dev{Scripts: map[string]string{}, Swap: Swap{Deployment: Deployment{Command: []string{}}}}, b: &Dev{}, expected: true},
+		{name: "reordered-args", a: base(), b: func() *Dev { d := base(); d.Swap.Deployment.Args = []string{"--port", "--debug"}; return d }(), expected: false},
+		{name: "different-mount", a: base(), b: func() *Dev { d := base(); d.Mounts[0].Target = "/src"; return d }(), expected: false},
+		{name: "different-script", a: base(), b: func() *Dev { d := base(); d.Scripts["test"] = "go test"; return d }(), expected: false},
+		{name: "nil-receiver", a: nilDev, b: base(), expected: false},
+		{name: "both-nil", a: nilDev, b: nilDev, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.a.Equal(tt.b) != tt.expected {
+				t.Errorf("%+v == %+v is not %t", tt.a, tt.b, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_DeepCopy(t *testing.T) {
+	enabled := true
+	uid := int64(1000)
+	dev := &Dev{
+		Swap:    Swap{Deployment: Deployment{Name: "deployment", Args: []string{"--debug"}}},
+		Mounts:  []Mount{{Source: ".", Target: "/app", Enabled: &enabled}},
+		Sync:    Sync{OwnerUID: &uid},
+		Scripts: map[string]string{"test": "make test"},
+	}
+
+	copied := dev.DeepCopy()
+	if !copied.Equal(dev) {
+		t.Fatalf("%+v != %+v", copied, dev)
+	}
+
+	copied.Scripts["test"] = "go test"
+	copied.Scripts["lint"] = "make lint"
+	copied.Swap.Deployment.Args[0] = "--verbose"
+	*copied.Mounts[0].Enabled = false
+	*copied.Sync.OwnerUID = 0
+
+	if len(dev.Scripts) != 1 || dev.Scripts["test"] != "make test" {
+		t.Errorf("original scripts were modified: %+v", dev.Scripts)
+	}
+
+	if dev.Swap.Deployment.Args[0] != "--debug" {
+		t.Errorf("original args were modified: %+v", dev.Swap.Deployment.Args)
+	}
+
+	if !*dev.Mounts[0].Enabled || *dev.Sync.OwnerUID != 1000 {
+		t.Errorf("original pointers were modified: %+v", dev)
+	}
+}
+
+func Test_expandHome(t *testing.T) {
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", "/home/cnd")
+	defer os.Setenv("HOME", home)
+
+	var tests = []struct {
+		path     string
+		expected string
+	}{
+		{path: "~/workspace", expected: "/home/cnd/workspace"},
+		{path: "/app", expected: "/app"},
+		{path: "./~/app", expected: "./~/app"},
+		{path: "~app", expected: "~app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := expandHome(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("%s != %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_expandHomeUnset(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("the home folder isn't read from $HOME")
+	}
+
+	home := os.Getenv("HOME")
+	os.Unsetenv("HOME")
+	defer os.Setenv("HOME", home)
+
+	if p, err := expandHome("/app"); err != nil || p != "/app" {
+		t.Errorf("absolute path wasn't kept: %s %v", p, err)
+	}
+
+	_, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: ~/workspace
+    target: /app`))
+	if err == nil || !strings.Contains(err.Error(), "home folder") {
+		t.Errorf("source relative to an unknown home folder was accepted: %v", err)
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	devPath := filepath.Join(dir, "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte(`
+swap:
+  deployment:
+    name: ""
+mounts:
+  - source: /does/not/exist
+    target: /app`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Validate(devPath)
+	ve, ok := err.(*ValidationError)
+	if !ok || len(ve.Errors) != 2 {
+		t.Errorf("wrong validation errors: %v", err)
+	}
+
+	if err := Validate(filepath.Join(dir, "missing.yml")); err == nil {
+		t.Errorf("missing manifest was accepted")
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// sharedBlockPrefix marks the top level keys of a multi dev manifest that hold shared blocks instead of a dev
+const sharedBlockPrefix = "x-"
+
+// ReadDevs returns the devs of a yaml manifest with a top level map of name to dev, e.g. one per service.
+// Each dev gets the defaults, validation and path resolution of ReadDev. Keys starting with x- are skipped,
+// so they can hold the blocks shared with yaml anchors and aliases
+func ReadDevs(devPath string) (map[string]*Dev, error) {
+	f, err := openManifest(devPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]interface{}
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		if !strings.HasPrefix(name, sharedBlockPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	devs := map[string]*Dev{}
+	for _, name := range names {
+		d, err := readNamedDev(entries[name], devPath)
+		if err != nil {
+			return nil, fmt.Errorf("dev '%s' is not valid: %w", name, err)
+		}
+
+		devs[name] = d
+	}
+
+	if err := ValidateEnvironments(devs); err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	return devs, nil
+}
+
+// readNamedDev decodes an entry of a multi dev manifest. The aliases are already resolved, but the entry is
+// encoded again, so its fields keep no line positions
+func readNamedDev(entry interface{}, devPath string) (*Dev, error) {
+	b, err := yaml.Marshal(entry)
+	if err != nil {
+		return nil, invalidManifest(err)
+	}
+
+	d, err := decodeDev(b, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for field := range d.positions {
+		d.positions[field] = position{}
+	}
+
+	if err := d.resolveAndValidate(filepath.Dir(devPath)); err != nil {
+		return nil, err
+	}
+
+	if err := d.resolveFiles(devPath, true); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
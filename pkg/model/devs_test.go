@@ -0,0 +1,156 @@
+package model
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ReadDevs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-devs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{"api", "web"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devPath := filepath.Join(dir, "dev.yml")
+	manifest := []byte(`
+x-defaults: &defaults
+  image: okteto/golang:1
+  command: make run
+api:
+  swap:
+    deployment:
+      <<: *defaults
+      name: api
+  mounts:
+    - source: api
+      target: /app
+web:
+  swap:
+    deployment:
+      <<: *defaults
+      name: web
+      image: okteto/node:10
+  mounts:
+    - source: web
+      target: /app`)
+	if err := ioutil.WriteFile(devPath, manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	devs, err := ReadDevs(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devs) != 2 {
+		t.Fatalf("wrong devs: %+v", devs)
+	}
+
+	api := devs["api"]
+	if api.Swap.Deployment.Name != "api" || api.Swap.Deployment.Image != "okteto/golang:1" || len(api.Swap.Deployment.Command) != 2 {
+		t.Errorf("the shared block wasn't applied: %+v", api.Swap.Deployment)
+	}
+
+	if expected := filepath.Join(dir, "api"); api.Mounts[0].Source != expected {
+		t.Errorf("%s != %s", api.Mounts[0].Source, expected)
+	}
+
+	if web := devs["web"]; web.Swap.Deployment.Image != "okteto/node:10" || web.Swap.Deployment.WorkDir != "/app" {
+		t.Errorf("the shared block wasn't overridden: %+v", web.Swap.Deployment)
+	}
+
+	if err := ioutil.WriteFile(devPath, []byte(strings.Replace(string(manifest), "name: web", `name: ""`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadDevs(devPath)
+	if err == nil || !strings.Contains(err.Error(), "dev 'web'") || !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("the invalid dev wasn't identified: %v", err)
+	}
+
+	if _, err := ReadDevs(filepath.Join(dir, "missing.yml")); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("missing manifest isn't ErrManifestNotFound: %v", err)
+	}
+}
+
+func Test_ReadDevsSelectors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-devs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	devPath := filepath.Join(dir, "dev.yml")
+	manifest := `
+api:
+  swap:
+    selector:
+      app: api
+  mounts:
+    - source: .
+      target: /app
+web:
+  swap:
+    selector:
+      app: web
+  mounts:
+    - source: .
+      target: /app`
+	if err := ioutil.WriteFile(devPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	devs, err := ReadDevs(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devs) != 2 {
+		t.Fatalf("wrong devs: %+v", devs)
+	}
+
+	if err := ioutil.WriteFile(devPath, []byte(strings.Replace(manifest, "app: web", "app: api", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDevs(devPath); err == nil || !strings.Contains(err.Error(), "swap the same deployment 'app=api'") {
+		t.Errorf("the environments with the same selector were accepted: %v", err)
+	}
+}
+
+func Test_ValidateEnvironmentsNamespaces(t *testing.T) {
+	devs := map[string]*Dev{
+		"staging":    {Swap: Swap{Deployment: Deployment{Name: "api", Namespace: "staging"}}},
+		"production": {Swap: Swap{Deployment: Deployment{Name: "api", Namespace: "production"}}},
+	}
+
+	if err := ValidateEnvironments(devs); err != nil {
+		t.Errorf("the environments of different namespaces were rejected: %s", err)
+	}
+
+	devs["production"].Swap.Deployment.Namespace = "staging"
+	if err := ValidateEnvironments(devs); err == nil || !strings.Contains(err.Error(), "swap the same deployment 'api'") {
+		t.Errorf("the environments of the same namespace were accepted: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// secretPrefix marks the ${SECRET:NAME} references, which are kept when the manifest is read
+// and only resolved from the environment when a script is rendered
+const secretPrefix = "SECRET:"
+
+// StrictEnvExpansion makes loading a manifest fail when it references an undefined environment variable
+var StrictEnvExpansion = false
+
+// expandEnv replaces the ${VAR} and $VAR references with the values of the environment variables. $$ is a literal $
+func expandEnv(value string) (string, error) {
+	var undefined []string
+	result := os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+
+		if strings.HasPrefix(name, secretPrefix) {
+			return "${" + name + "}"
+		}
+
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+
+		return v
+	})
+
+	if StrictEnvExpansion && len(undefined) > 0 {
+		return "", fmt.Errorf("undefined environment variables in '%s': %s", value, strings.Join(undefined, ", "))
+	}
+
+	return result, nil
+}
+
+// expandEnvFields expands the environment variables of the string fields of the manifest
+func (dev *Dev) expandEnvFields() error {
+	fields := []*string{&dev.Swap.Deployment.Name, &dev.Swap.Deployment.Image, &dev.Swap.Deployment.WorkDir, &dev.Swap.Deployment.Namespace, &dev.SyncImage}
+	for i := range dev.Mounts {
+		fields = append(fields, &dev.Mounts[i].Source, &dev.Mounts[i].Target)
+	}
+
+	for i := range dev.Swap.Deployment.Containers {
+		fields = append(fields, &dev.Swap.Deployment.Containers[i].Image, &dev.Swap.Deployment.Containers[i].Target)
+	}
+
+	for i := range dev.Environment {
+		fields = append(fields, &dev.Environment[i].Value)
+	}
+
+	for _, f := range fields {
+		expanded, err := expandEnv(*f)
+		if err != nil {
+			return err
+		}
+		*f = expanded
+	}
+
+	for name, script := range dev.Scripts {
+		expanded, err := expandEnv(script)
+		if err != nil {
+			return fmt.Errorf("script '%s': %s", name, err)
+		}
+		dev.Scripts[name] = expanded
+	}
+
+	return nil
+}
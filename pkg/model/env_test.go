@@ -0,0 +1,87 @@
+package model
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_loadDevExpandEnv(t *testing.T) {
+	os.Setenv("CND_TEST_SHA", "0a1b2c")
+	os.Setenv("CND_TEST_CHECKOUT", "/checkout")
+	defer os.Unsetenv("CND_TEST_SHA")
+	defer os.Unsetenv("CND_TEST_CHECKOUT")
+
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+    image: myreg.io/app:${CND_TEST_SHA}
+mounts:
+  - source: $CND_TEST_CHECKOUT/app
+    target: /app
+scripts:
+  price: "echo $$5 ${CND_TEST_UNDEFINED}"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Swap.Deployment.Image != "myreg.io/app:0a1b2c" {
+		t.Errorf("image was not expanded: %s", d.Swap.Deployment.Image)
+	}
+
+	if d.Mounts[0].Source != "/checkout/app" {
+		t.Errorf("source was not expanded: %s", d.Mounts[0].Source)
+	}
+
+	if d.Scripts["price"] != "echo $5 " {
+		t.Errorf("script was not expanded: %s", d.Scripts["price"])
+	}
+}
+
+func Test_loadDevExpandEnvName(t *testing.T) {
+	var tests = []struct {
+		name     string
+		suffix   string
+		set      bool
+		expected string
+	}{
+		{name: "set", suffix: "-staging", set: true, expected: "proj-api-staging"},
+		{name: "empty", suffix: "", set: true, expected: "proj-api"},
+		{name: "unset", set: false, expected: "proj-api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				os.Setenv("CND_TEST_SUFFIX", tt.suffix)
+				defer os.Unsetenv("CND_TEST_SUFFIX")
+			}
+
+			d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: proj-api${CND_TEST_SUFFIX}
+    container: api`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Swap.Deployment.Name != tt.expected {
+				t.Errorf("%s != %s", d.Swap.Deployment.Name, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_expandEnvStrict(t *testing.T) {
+	StrictEnvExpansion = true
+	defer func() { StrictEnvExpansion = false }()
+
+	if _, err := expandEnv("${CND_TEST_UNDEFINED}"); err == nil {
+		t.Errorf("undefined variable was accepted")
+	}
+
+	if v, err := expandEnv("$$HOME"); err != nil || v != "$HOME" {
+		t.Errorf("escaped variable was expanded: %s %v", v, err)
+	}
+}
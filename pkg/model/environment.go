@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EnvVar is an environment variable set in the dev container
+type EnvVar struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// UnmarshalYAML accepts both the NAME=value shorthand and the structured name/value entry
+func (e *EnvVar) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err == nil {
+		parts := strings.SplitN(raw, "=", 2)
+		e.Name = parts[0]
+		if len(parts) == 2 {
+			e.Value = parts[1]
+		}
+
+		return nil
+	}
+
+	type envVar EnvVar
+	var structured envVar
+	if err := unmarshal(&structured); err != nil {
+		return err
+	}
+
+	*e = EnvVar(structured)
+	return nil
+}
+
+// UnmarshalJSON accepts both the NAME=value shorthand and the structured name/value entry
+func (e *EnvVar) UnmarshalJSON(b []byte) error {
+	return e.UnmarshalYAML(func(v interface{}) error {
+		return json.Unmarshal(b, v)
+	})
+}
+
+func (dev *Dev) validateEnvironment() []*FieldError {
+	var errs []*FieldError
+	for i, e := range dev.Environment {
+		if e.Name == "" {
+			errs = append(errs, dev.fieldErrorf("environment", "Environment variable %d must have a name", i))
+		}
+
+		if strings.Contains(e.Name, "=") {
+			errs = append(errs, dev.fieldErrorf("environment", "Environment variable name '%s' cannot contain '='", e.Name))
+		}
+	}
+
+	return errs
+}
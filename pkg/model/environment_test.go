@@ -0,0 +1,63 @@
+package model
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_loadDevEnvironment(t *testing.T) {
+	os.Setenv("CND_TEST_HOME", "/home/cnd")
+	defer os.Unsetenv("CND_TEST_HOME")
+
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+environment:
+  - DEBUG=true
+  - FOO=${CND_TEST_HOME}/bar
+  - EMPTY
+  - name: URL
+    value: http://localhost?a=b`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []EnvVar{
+		{Name: "DEBUG", Value: "true"},
+		{Name: "FOO", Value: "/home/cnd/bar"},
+		{Name: "EMPTY"},
+		{Name: "URL", Value: "http://localhost?a=b"},
+	}
+
+	if !reflect.DeepEqual(d.Environment, expected) {
+		t.Errorf("%+v != %+v", d.Environment, expected)
+	}
+}
+
+func Test_validateEnvironment(t *testing.T) {
+	var tests = []struct {
+		name        string
+		environment []EnvVar
+		valid       bool
+	}{
+		{name: "valid", environment: []EnvVar{{Name: "DEBUG", Value: "true"}}, valid: true},
+		{name: "empty-name", environment: []EnvVar{{Value: "true"}}, valid: false},
+		{name: "name-with-equal", environment: []EnvVar{{Name: "A=B", Value: "true"}}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Environment: tt.environment}
+			errs := dev.validateEnvironment()
+			if tt.valid && len(errs) != 0 {
+				t.Errorf("valid environment was rejected: %v", errs)
+			}
+
+			if !tt.valid && len(errs) == 0 {
+				t.Errorf("invalid environment was accepted")
+			}
+		})
+	}
+}
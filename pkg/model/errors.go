@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrManifestNotFound indicates the manifest file doesn't exist
+	ErrManifestNotFound = fmt.Errorf("manifest not found")
+
+	// ErrInvalidManifest indicates the manifest can't be decoded or doesn't pass the validation
+	ErrInvalidManifest = fmt.Errorf("invalid manifest")
+
+	// ErrEmptyManifest indicates the manifest has no content other than whitespace and comments
+	ErrEmptyManifest = fmt.Errorf("manifest is empty")
+
+	// ErrSourceMissing indicates the source folder of an enabled mount doesn't exist
+	ErrSourceMissing = fmt.Errorf("mount source is missing")
+)
+
+// ManifestError is an error reading a manifest. It keeps the message of the original error,
+// and matches its Kind with errors.Is, e.g. errors.Is(err, ErrManifestNotFound)
+type ManifestError struct {
+	Kind error
+	Err  error
+}
+
+func (e *ManifestError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original error
+func (e *ManifestError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if target is the kind of the error
+func (e *ManifestError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// invalidManifest marks err as an ErrInvalidManifest
+func invalidManifest(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ManifestError{Kind: ErrInvalidManifest, Err: err}
+}
+
+// openManifest opens the manifest file, marking a missing file as an ErrManifestNotFound
+func openManifest(devPath string) (*os.File, error) {
+	f, err := os.Open(devPath)
+	if err != nil && os.IsNotExist(err) {
+		return nil, &ManifestError{Kind: ErrManifestNotFound, Err: err}
+	}
+
+	return f, err
+}
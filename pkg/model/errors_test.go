@@ -0,0 +1,126 @@
+package model
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ManifestErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	missing := filepath.Join(dir, "missing.yml")
+	_, err = ReadDev(missing)
+	if !errors.Is(err, ErrManifestNotFound) || !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("missing manifest isn't ErrManifestNotFound: %v", err)
+	}
+
+	if err := Validate(missing); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("missing manifest isn't ErrManifestNotFound: %v", err)
+	}
+
+	var tests = []struct {
+		name          string
+		manifest      string
+		sourceMissing bool
+	}{
+		{
+			name:     "malformed",
+			manifest: "swap: [",
+		},
+		{
+			name:     "unknown-field",
+			manifest: "swap:\n  deployment:\n    name: deployment\nmont: {}",
+		},
+		{
+			name:     "invalid",
+			manifest: "swap:\n  deployment:\n    name: \"\"",
+		},
+		{
+			name:          "source-missing",
+			manifest:      "swap:\n  deployment:\n    name: deployment\nmounts:\n  - source: /does/not/exist\n    target: /app",
+			sourceMissing: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devPath := filepath.Join(dir, tt.name+".yml")
+			if err := ioutil.WriteFile(devPath, []byte(tt.manifest), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ReadDev(devPath)
+			if !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("error isn't ErrInvalidManifest: %v", err)
+			}
+
+			if errors.Is(err, ErrManifestNotFound) {
+				t.Errorf("error is ErrManifestNotFound: %v", err)
+			}
+
+			if errors.Is(err, ErrSourceMissing) != tt.sourceMissing {
+				t.Errorf("wrong ErrSourceMissing match: %v", err)
+			}
+		})
+	}
+}
+
+func Test_ManifestErrorMessages(t *testing.T) {
+	_, err := LoadDev([]byte("mount:\n  target: /app\nmounts:\n  - target: /src"))
+	if !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("error isn't ErrInvalidManifest: %v", err)
+	}
+
+	var me *ManifestError
+	if !errors.As(err, &me) || me.Kind != ErrInvalidManifest {
+		t.Errorf("error isn't a ManifestError: %v", err)
+	}
+
+	expected := "'mount' and 'mounts' cannot be used together"
+	if err.Error() != expected {
+		t.Errorf("%s != %s", err.Error(), expected)
+	}
+
+	dev := &Dev{Mounts: []Mount{{Source: "/does/not/exist", Target: "/app"}}}
+	err = dev.validate()
+	var ve *ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, ErrSourceMissing) {
+		t.Errorf("error isn't a ValidationError with a missing source: %v", err)
+	}
+
+	expected = "mounts[0].source: Source mount folder /does/not/exist does not exists"
+	if ve != nil && ve.Errors[0].Error() != expected {
+		t.Errorf("%s != %s", ve.Errors[0].Error(), expected)
+	}
+}
+
+func Test_LoadDevEmpty(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+	}{
+		{name: "zero-bytes", manifest: ""},
+		{name: "whitespace", manifest: "  \n\t\n"},
+		{name: "comments", manifest: "# swap:\n#   deployment:\n#     name: api\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDev([]byte(tt.manifest))
+			if !errors.Is(err, ErrEmptyManifest) || !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("empty manifest wasn't detected: %v", err)
+			}
+
+			if err != nil && err.Error() != "manifest is empty" {
+				t.Errorf("wrong error: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// pseudoFilesystems are mount points that syncthing can't index
+	pseudoFilesystems = []string{"/proc", "/sys", "/dev"}
+)
+
+func validateSourceFilesystem(source string) error {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pseudoFilesystems {
+		if abs == p || strings.HasPrefix(abs, p+"/") {
+			return fmt.Errorf("Source mount folder %s is on the %s pseudo filesystem", abs, p)
+		}
+	}
+
+	network, err := isNetworkFilesystem(abs)
+	if err != nil {
+		log.Debugf("failed to detect the filesystem of %s: %s", abs, err)
+		return nil
+	}
+
+	if network {
+		log.Warnf("Source mount folder %s is on a network filesystem, file synchronization might not work as expected", abs)
+	}
+
+	return nil
+}
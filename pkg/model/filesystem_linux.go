@@ -0,0 +1,22 @@
+package model
+
+import "syscall"
+
+// magic numbers of the network filesystems, as defined in linux/magic.h
+var networkFilesystemTypes = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517B:     true, // SMB
+	0xFE534D42: true, // SMB2
+	0xFF534D42: true, // CIFS
+	0x564C:     true, // NCP
+	0x65735546: true, // FUSE, mostly used by sshfs
+}
+
+func isNetworkFilesystem(path string) (bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false, err
+	}
+
+	return networkFilesystemTypes[uint32(st.Type)], nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package model
+
+// isNetworkFilesystem can't be detected in this platform, so the check is skipped
+func isNetworkFilesystem(path string) (bool, error) {
+	return false, nil
+}
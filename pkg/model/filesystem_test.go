@@ -0,0 +1,35 @@
+package model
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_validateSourceFilesystem(t *testing.T) {
+	wd, _ := os.Getwd()
+
+	var tests = []struct {
+		name   string
+		source string
+		fails  bool
+	}{
+		{name: "proc", source: "/proc", fails: true},
+		{name: "proc-subfolder", source: "/proc/self", fails: true},
+		{name: "sys", source: "/sys/kernel", fails: true},
+		{name: "prefix", source: "/processes", fails: false},
+		{name: "working-directory", source: wd, fails: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSourceFilesystem(tt.source)
+			if tt.fails && err == nil {
+				t.Errorf("%s was accepted", tt.source)
+			}
+
+			if !tt.fails && err != nil {
+				t.Errorf("%s was rejected: %s", tt.source, err)
+			}
+		})
+	}
+}
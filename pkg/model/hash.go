@@ -0,0 +1,42 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// Hash returns a stable sha256 of the effective manifest, e.g. to skip re-applying an unchanged dev.
+// Devs that are Equal have the same hash, whatever the order of their maps
+func (dev *Dev) Hash() string {
+	// encoding/json sorts the map keys, so the output is deterministic
+	b, err := json.Marshal(dev.normalized())
+	if err != nil {
+		b = []byte(fmt.Sprintf("%+v", dev.normalized()))
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// AreaHashes returns a hash of each high level area of the dev (swap, mounts, sync and scripts), to detect which ones changed
+func (dev *Dev) AreaHashes() map[string]string {
+	areas := map[string]interface{}{
+		"swap":    dev.Swap,
+		"mounts":  dev.Mounts,
+		"sync":    dev.Sync,
+		"scripts": dev.Scripts,
+	}
+
+	hashes := make(map[string]string, len(areas))
+	for name, area := range areas {
+		// encoding/json sorts the map keys, so the output is deterministic
+		b, err := json.Marshal(area)
+		if err != nil {
+			b = []byte(fmt.Sprintf("%+v", area))
+		}
+
+		hashes[name] = fmt.Sprintf("%x", sha256.Sum256(b))
+	}
+
+	return hashes
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+)
+
+func Test_Hash(t *testing.T) {
+	a, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+    command: make run
+scripts:
+  test: make test
+  build: make build`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Dev{
+		Swap:    Swap{Deployment: Deployment{Name: "deployment", Command: Command{"make", "run"}}},
+		Mounts:  []Mount{{Source: ".", Target: DefaultMountTarget}},
+		Scripts: map[string]string{"build": "make build", "test": "make test"},
+	}
+
+	if a.Hash() != b.Hash() {
+		t.Errorf("equal devs have different hashes: %s != %s", a.Hash(), b.Hash())
+	}
+
+	if len(a.Hash()) != 64 {
+		t.Errorf("wrong hash: %s", a.Hash())
+	}
+
+	b.Swap.Deployment.Args = []string{"--debug"}
+	if a.Hash() == b.Hash() {
+		t.Errorf("different devs have the same hash: %s", a.Hash())
+	}
+}
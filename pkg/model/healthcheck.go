@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	// DefaultHealthcheckTimeout is how long up waits for the healthcheck to pass by default
+	DefaultHealthcheckTimeout = 60 * time.Second
+
+	// DefaultHealthcheckInterval is how long up waits between the healthcheck attempts by default
+	DefaultHealthcheckInterval = 2 * time.Second
+)
+
+// Healthcheck is the command that must succeed in the dev container before it is considered ready. cnd up doesn't run it yet
+type Healthcheck struct {
+	Command  Command `json:"command,omitempty" yaml:"command,omitempty"`
+	Timeout  string  `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Interval string  `json:"interval,omitempty" yaml:"interval,omitempty"`
+}
+
+// GetTimeout returns how long to wait for the healthcheck to pass, DefaultHealthcheckTimeout by default
+func (h *Healthcheck) GetTimeout() time.Duration {
+	return parseHealthcheckDuration(h.Timeout, DefaultHealthcheckTimeout)
+}
+
+// GetInterval returns how long to wait between the healthcheck attempts, DefaultHealthcheckInterval by default
+func (h *Healthcheck) GetInterval() time.Duration {
+	return parseHealthcheckDuration(h.Interval, DefaultHealthcheckInterval)
+}
+
+// parseHealthcheckDuration returns the duration, or the default if it's empty or invalid, which validate rejects
+func parseHealthcheckDuration(value string, defaultValue time.Duration) time.Duration {
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+
+	return d
+}
+
+// validateHealthcheck checks that a healthcheck has a command and valid durations. Without a healthcheck, up doesn't wait
+func (dev *Dev) validateHealthcheck() []*FieldError {
+	if dev.Healthcheck == nil {
+		return nil
+	}
+
+	var errs []*FieldError
+	if len(dev.Healthcheck.Command) == 0 {
+		errs = append(errs, dev.fieldErrorf("healthcheck.command", "Healthcheck command cannot be empty"))
+	}
+
+	durations := []struct {
+		field string
+		value string
+	}{
+		{field: "healthcheck.timeout", value: dev.Healthcheck.Timeout},
+		{field: "healthcheck.interval", value: dev.Healthcheck.Interval},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		if parsed, err := time.ParseDuration(d.value); err != nil || parsed <= 0 {
+			errs = append(errs, dev.fieldErrorf(d.field, "Healthcheck duration '%s' is not valid, it must be a positive duration, e.g. 30s", d.value))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_loadDevHealthcheck(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment: api
+healthcheck:
+  command: curl -f http://localhost:8080/healthz
+  timeout: 2m`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Command{"curl", "-f", "http://localhost:8080/healthz"}
+	if d.Healthcheck == nil || !reflect.DeepEqual(d.Healthcheck.Command, expected) {
+		t.Fatalf("the healthcheck wasn't parsed: %+v", d.Healthcheck)
+	}
+
+	if d.Healthcheck.GetTimeout() != 2*time.Minute || d.Healthcheck.GetInterval() != DefaultHealthcheckInterval {
+		t.Errorf("wrong durations: %s %s", d.Healthcheck.GetTimeout(), d.Healthcheck.GetInterval())
+	}
+
+	d, err = LoadDev([]byte("swap:\n  deployment: api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Healthcheck != nil {
+		t.Errorf("a healthcheck was defined: %+v", d.Healthcheck)
+	}
+}
+
+func Test_validateHealthcheck(t *testing.T) {
+	var tests = []struct {
+		name        string
+		healthcheck *Healthcheck
+		expected    string
+	}{
+		{name: "absent", healthcheck: nil},
+		{name: "valid", healthcheck: &Healthcheck{Command: Command{"true"}, Timeout: "30s", Interval: "500ms"}},
+		{name: "defaults", healthcheck: &Healthcheck{Command: Command{"true"}}},
+		{name: "no-command", healthcheck: &Healthcheck{Timeout: "30s"}, expected: "Healthcheck command cannot be empty"},
+		{name: "invalid-timeout", healthcheck: &Healthcheck{Command: Command{"true"}, Timeout: "30"}, expected: "Healthcheck duration '30' is not valid"},
+		{name: "negative-interval", healthcheck: &Healthcheck{Command: Command{"true"}, Interval: "-1s"}, expected: "Healthcheck duration '-1s' is not valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Healthcheck: tt.healthcheck}
+			errs := dev.validateHealthcheck()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid healthcheck was rejected: %v", errs)
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// CNDIgnoreFile is the file next to the manifest with additional ignore patterns, one per line
+	CNDIgnoreFile = ".cndignore"
+)
+
+// loadIgnoreFile adds the patterns of the .cndignore file of the folder, if it exists, to the ignored patterns
+func (dev *Dev) loadIgnoreFile(dir string) error {
+	f, err := os.Open(filepath.Join(dir, CNDIgnoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+	defer f.Close()
+
+	existing := map[string]bool{}
+	for _, p := range dev.Ignore {
+		existing[p] = true
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" || strings.HasPrefix(p, "#") || existing[p] {
+			continue
+		}
+
+		dev.Ignore = append(dev.Ignore, p)
+		existing[p] = true
+	}
+
+	return scanner.Err()
+}
+
+func (dev *Dev) validateIgnore() []*FieldError {
+	var errs []*FieldError
+	for i, p := range dev.Ignore {
+		if strings.ContainsAny(p, "\r\n") {
+			errs = append(errs, dev.fieldErrorf("ignore", "Ignore pattern %d cannot contain new lines", i))
+		}
+	}
+
+	return errs
+}
+
+// validateMountIgnore checks the ignore patterns of each mount, which can't be empty
+func (dev *Dev) validateMountIgnore() []*FieldError {
+	var errs []*FieldError
+	for i, m := range dev.Mounts {
+		for j, p := range m.Ignore {
+			if strings.TrimSpace(p) == "" {
+				errs = append(errs, dev.fieldErrorf(dev.mountField(i, "ignore"), "Ignore pattern %d cannot be empty", j))
+				continue
+			}
+
+			if strings.ContainsAny(p, "\r\n") {
+				errs = append(errs, dev.fieldErrorf(dev.mountField(i, "ignore"), "Ignore pattern %d cannot contain new lines", j))
+			}
+		}
+	}
+
+	return errs
+}
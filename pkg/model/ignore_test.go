@@ -0,0 +1,88 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadDevIgnore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	devPath := filepath.Join(dir, "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - target: /app
+ignore:
+  - .git`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(d.Ignore, []string{".git"}) {
+		t.Errorf("ignore was not parsed: %+v", d.Ignore)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, CNDIgnoreFile), []byte("# dependencies\nnode_modules\n\n.git\n*.log\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err = ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{".git", "node_modules", "*.log"}
+	if !reflect.DeepEqual(d.Ignore, expected) {
+		t.Errorf("%+v != %+v", d.Ignore, expected)
+	}
+}
+
+func Test_validateIgnore(t *testing.T) {
+	dev := &Dev{Ignore: []string{"node_modules", "a\nb"}}
+	errs := dev.validateIgnore()
+	if len(errs) != 1 || errs[0].Field != "ignore" {
+		t.Errorf("pattern with a new line was accepted: %v", errs)
+	}
+}
+
+func Test_validateMountIgnore(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: ./frontend
+    target: /frontend
+    ignore:
+      - node_modules
+  - source: ./api
+    target: /api
+    ignore:
+      - ""`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(d.Mounts[0].Ignore, []string{"node_modules"}) {
+		t.Errorf("mount ignore was not parsed: %+v", d.Mounts[0])
+	}
+
+	errs := d.validateMountIgnore()
+	if len(errs) != 1 || errs[0].Field != "mounts[1].ignore" {
+		t.Errorf("empty pattern was accepted: %v", errs)
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	dockerHubLibrary = "library"
+)
+
+var (
+	// imageReferenceRegexp follows the docker reference grammar: [registry/]repository[:tag][@digest]
+	imageReferenceRegexp = regexp.MustCompile(`^((?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*)*(?::[\w][\w.-]{0,127})?(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+	registryHostRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+)
+
+// ApplyRegistryMirror returns a copy of the dev with its images pulled from the given registry mirror.
+// The sync images are mirrored too, including the default ones
+func (dev *Dev) ApplyRegistryMirror(mirror string) (*Dev, error) {
+	mirror = strings.TrimSuffix(mirror, "/")
+	if !registryHostRegexp.MatchString(mirror) {
+		return nil, fmt.Errorf("'%s' is not a valid registry host", mirror)
+	}
+
+	d := dev.DeepCopy()
+	if d.Swap.Deployment.Image != "" {
+		d.Swap.Deployment.Image = fmt.Sprintf("%s/%s", mirror, imageRepositoryPath(d.Swap.Deployment.Image))
+	}
+
+	d.SyncImage = fmt.Sprintf("%s/%s", mirror, imageRepositoryPath(d.GetSyncImage()))
+	d.initSyncImage = fmt.Sprintf("%s/%s", mirror, imageRepositoryPath(d.GetInitSyncImage()))
+
+	for i, c := range d.Swap.Deployment.Containers {
+		if c.Image != "" {
+			d.Swap.Deployment.Containers[i].Image = fmt.Sprintf("%s/%s", mirror, imageRepositoryPath(c.Image))
+		}
+	}
+
+	return d, nil
+}
+
+// validateImage checks that an image is a valid docker reference, e.g. okteto/cnd:latest
+func validateImage(image string) error {
+	if !imageReferenceRegexp.MatchString(image) {
+		return fmt.Errorf("'%s' is not a valid image reference", image)
+	}
+
+	// the first component is part of the repository, and must be lowercase, unless it's a registry host
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && !isRegistryHost(parts[0]) && parts[0] != strings.ToLower(parts[0]) {
+		return fmt.Errorf("'%s' is not a valid image reference, repository names must be lowercase", image)
+	}
+
+	return nil
+}
+
+// ValidateStrictImage checks that the images of the dev are pinned to a tag other than latest or to a digest,
+// so the environments are reproducible. It's opt-in, validate accepts floating images
+func (dev *Dev) ValidateStrictImage() error {
+	var errs []*FieldError
+	check := func(field, image string) {
+		if image != "" && isFloatingImage(image) {
+			errs = append(errs, dev.fieldErrorf(field, "Image '%s' is not pinned, use a tag other than latest or a digest, e.g. '%s:1.0.0'", image, imageWithoutTag(image)))
+		}
+	}
+
+	check("swap.deployment.image", dev.Swap.Deployment.Image)
+	for _, c := range dev.Swap.Deployment.Containers {
+		check("swap.deployment.containers", c.Image)
+	}
+	check("syncImage", dev.SyncImage)
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// isFloatingImage returns true if the image has no tag or the latest tag, and no digest
+func isFloatingImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	tag := imageTag(image)
+	return tag == "" || tag == "latest"
+}
+
+// imageTag returns the tag of an image without a digest, or an empty string if it's untagged
+func imageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+
+	return ""
+}
+
+// imageWithoutTag returns the image reference without its tag
+func imageWithoutTag(image string) string {
+	if tag := imageTag(image); tag != "" {
+		return strings.TrimSuffix(image, ":"+tag)
+	}
+
+	return image
+}
+
+// imageRepositoryPath returns the image reference without its registry host, e.g. okteto/cnd:latest for gcr.io/okteto/cnd:latest
+func imageRepositoryPath(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 {
+		return fmt.Sprintf("%s/%s", dockerHubLibrary, image)
+	}
+
+	if isRegistryHost(parts[0]) {
+		return parts[1]
+	}
+
+	return image
+}
+
+// isRegistryHost follows the docker convention: the first component is a registry if it has a dot, a port or is localhost
+func isRegistryHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
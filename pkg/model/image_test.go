@@ -0,0 +1,135 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ApplyRegistryMirror(t *testing.T) {
+	var tests = []struct {
+		name     string
+		image    string
+		mirror   string
+		expected string
+	}{
+		{name: "official", image: "python:3", mirror: "mirror.local:5000", expected: "mirror.local:5000/library/python:3"},
+		{name: "hub", image: "okteto/cnd:0.1", mirror: "mirror.local", expected: "mirror.local/okteto/cnd:0.1"},
+		{name: "registry", image: "gcr.io/okteto/cnd@sha256:abc", mirror: "mirror.local/", expected: "mirror.local/okteto/cnd@sha256:abc"},
+		{name: "localhost", image: "localhost/cnd", mirror: "mirror.local/proxy", expected: "mirror.local/proxy/cnd"},
+		{name: "empty", image: "", mirror: "mirror.local", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Image: tt.image}}, SyncImage: tt.image}
+			result, err := dev.ApplyRegistryMirror(tt.mirror)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Swap.Deployment.Image != tt.expected {
+				t.Errorf("%s != %s", result.Swap.Deployment.Image, tt.expected)
+			}
+
+			if tt.image != "" && result.SyncImage != tt.expected {
+				t.Errorf("%s != %s", result.SyncImage, tt.expected)
+			}
+
+			if dev.Swap.Deployment.Image != tt.image {
+				t.Errorf("original dev was modified: %s", dev.Swap.Deployment.Image)
+			}
+		})
+	}
+
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Image: "python"}}}
+	result, err := dev.ApplyRegistryMirror("mirror.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if image := result.GetSyncImage(); image != "mirror.local/okteto/syncthing:latest" {
+		t.Errorf("the default sync image wasn't mirrored: %s", image)
+	}
+
+	if image := result.GetInitSyncImage(); image != "mirror.local/okteto/init-syncthing:0.3.4" {
+		t.Errorf("the init sync image wasn't mirrored: %s", image)
+	}
+
+	if dev.SyncImage != "" || dev.GetInitSyncImage() != DefaultInitSyncImage {
+		t.Errorf("original dev was modified: %+v", dev)
+	}
+
+	for _, mirror := range []string{"", "my mirror", "https://mirror.local", "-mirror"} {
+		if _, err := dev.ApplyRegistryMirror(mirror); err == nil {
+			t.Errorf("'%s' was accepted as a mirror", mirror)
+		}
+	}
+}
+
+func Test_validateImage(t *testing.T) {
+	var tests = []struct {
+		image string
+		valid bool
+	}{
+		{image: "python", valid: true},
+		{image: "python:3.7-alpine", valid: true},
+		{image: "okteto/cnd:latest", valid: true},
+		{image: "gcr.io/okteto/cnd", valid: true},
+		{image: "localhost:5000/my_app__v2:dev", valid: true},
+		{image: "okteto/cnd@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", valid: true},
+		{image: "my app:latest", valid: false},
+		{image: "Okteto/cnd", valid: false},
+		{image: "okteto//cnd", valid: false},
+		{image: "okteto/cnd:", valid: false},
+		{image: ":latest", valid: false},
+		{image: "  ", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			err := validateImage(tt.image)
+			if tt.valid && err != nil {
+				t.Errorf("valid image was rejected: %s", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("invalid image was accepted")
+			}
+		})
+	}
+}
+
+func Test_ValidateStrictImage(t *testing.T) {
+	var tests = []struct {
+		name  string
+		image string
+		valid bool
+	}{
+		{name: "keep-image", image: "", valid: true},
+		{name: "untagged", image: "okteto/cnd", valid: false},
+		{name: "latest", image: "okteto/cnd:latest", valid: false},
+		{name: "registry-port", image: "localhost:5000/okteto/cnd", valid: false},
+		{name: "tag", image: "okteto/cnd:1.0.0", valid: true},
+		{name: "registry-port-tag", image: "localhost:5000/okteto/cnd:1.0.0", valid: true},
+		{name: "digest", image: "okteto/cnd@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Image: tt.image}}, SyncImage: "okteto/syncthing:1.0"}
+			err := dev.ValidateStrictImage()
+			if tt.valid && err != nil {
+				t.Errorf("pinned image was rejected: %s", err)
+			}
+
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "not pinned")) {
+				t.Errorf("floating image '%s' was accepted: %v", tt.image, err)
+			}
+		})
+	}
+
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Containers: []ContainerSwap{{Name: "worker", Image: "okteto/worker"}}}}}
+	if err := dev.ValidateStrictImage(); err == nil {
+		t.Errorf("floating container image was accepted")
+	}
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// includedFile is the part of an included file merged into the dev
+type includedFile struct {
+	Include []string          `yaml:"include"`
+	Scripts map[string]string `yaml:"scripts"`
+}
+
+// userDefaultsFile is the file in the cnd home with the scripts available to every manifest
+const userDefaultsFile = "defaults.yml"
+
+// resolveIncludes merges the scripts of the included files, resolved against dir, into the dev.
+// The scripts of the dev take precedence over the included ones
+func (dev *Dev) resolveIncludes(dir string) error {
+	included, err := loadIncludes(dev.Include, dir, map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	dev.mergeScripts(included)
+	return nil
+}
+
+// resolveUserDefaults merges the scripts of the defaults file in the cnd home into the dev, if it exists.
+// The scripts of the dev and of its includes take precedence over the defaults
+func (dev *Dev) resolveUserDefaults() error {
+	p := filepath.Join(cndHomePath(), userDefaultsFile)
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return nil
+	}
+
+	defaults, err := loadIncludes([]string{p}, "", map[string]bool{})
+	if err != nil {
+		return err
+	}
+
+	dev.mergeScripts(defaults)
+	return nil
+}
+
+// mergeScripts adds the scripts not defined by the dev
+func (dev *Dev) mergeScripts(scripts map[string]string) {
+	if len(scripts) == 0 {
+		return
+	}
+
+	if dev.Scripts == nil {
+		dev.Scripts = map[string]string{}
+	}
+
+	for name, command := range scripts {
+		if _, ok := dev.Scripts[name]; !ok {
+			dev.Scripts[name] = command
+		}
+	}
+}
+
+// loadIncludes returns the scripts of the included files, the later files taking precedence.
+// visiting holds the files being included, to detect cycles
+func loadIncludes(includes []string, dir string, visiting map[string]bool) (map[string]string, error) {
+	scripts := map[string]string{}
+	for _, include := range includes {
+		p, err := expandHome(include)
+		if err != nil {
+			return nil, err
+		}
+
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+
+		p, err = filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if visiting[p] {
+			return nil, fmt.Errorf("include '%s' is a cycle, it's already being included", include)
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("include '%s' doesn't exist", include)
+			}
+
+			return nil, fmt.Errorf("error reading include '%s': %s", include, err)
+		}
+
+		var f includedFile
+		if err := yaml.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("error reading include '%s': %s", include, err)
+		}
+
+		visiting[p] = true
+		nested, err := loadIncludes(f.Include, filepath.Dir(p), visiting)
+		delete(visiting, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, command := range nested {
+			scripts[name] = command
+		}
+
+		for name, command := range f.Scripts {
+			scripts[name] = command
+		}
+	}
+
+	return scripts, nil
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ReadDevInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "common"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"cnd.yml": `
+swap:
+  deployment:
+    name: api
+mounts:
+  - source: .
+    target: /app
+include:
+  - ./common/scripts.yml
+scripts:
+  test: go test ./...`,
+		"common/scripts.yml": `
+include:
+  - base.yml
+scripts:
+  test: make test
+  build: make build`,
+		"common/base.yml": `
+scripts:
+  build: go build
+  lint: golint ./...`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := ReadDev(filepath.Join(dir, "cnd.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"test": "go test ./...", "build": "make build", "lint": "golint ./..."}
+	for name, command := range expected {
+		if d.Scripts[name] != command {
+			t.Errorf("script %s: %s != %s", name, d.Scripts[name], command)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "common", "base.yml"), []byte("include:\n  - scripts.yml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(filepath.Join(dir, "cnd.yml")); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("include cycle was accepted: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "common", "base.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(filepath.Join(dir, "cnd.yml")); err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("missing include was accepted: %v", err)
+	}
+}
+
+func Test_ReadDevUserDefaults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-defaults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := filepath.Join(dir, ".cnd")
+	defer os.Setenv("CND_HOME", os.Getenv("CND_HOME"))
+	os.Setenv("CND_HOME", home)
+
+	if err := os.MkdirAll(filepath.Join(dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	devPath := filepath.Join(dir, "api", "cnd.yml")
+	manifest := []byte(`
+swap:
+  deployment:
+    name: api
+mounts:
+  - source: .
+    target: /app
+scripts:
+  logs: tail -f /var/log/api.log`)
+	if err := ioutil.WriteFile(devPath, manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ReadDev(devPath)
+	if err != nil {
+		t.Fatalf("missing defaults file wasn't ignored: %s", err)
+	}
+
+	if len(d.Scripts) != 1 {
+		t.Errorf("wrong scripts: %+v", d.Scripts)
+	}
+
+	if err := os.MkdirAll(home, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(home, "defaults.yml"), []byte("scripts:\n  logs: tail -f /dev/null\n  shell: bash"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err = ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"logs": "tail -f /var/log/api.log", "shell": "bash"}
+	if !reflect.DeepEqual(d.Scripts, expected) {
+		t.Errorf("%+v != %+v", d.Scripts, expected)
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"strings"
+)
+
+// Lifecycle references the scripts run at the lifecycle events of the dev container, in order
+type Lifecycle struct {
+	PostStart []string `json:"postStart,omitempty" yaml:"postStart,omitempty"`
+	PreStop   []string `json:"preStop,omitempty" yaml:"preStop,omitempty"`
+}
+
+func (dev *Dev) validateLifecycle() []*FieldError {
+	var errs []*FieldError
+	events := []struct {
+		field   string
+		scripts []string
+	}{
+		{field: "lifecycle.postStart", scripts: dev.Lifecycle.PostStart},
+		{field: "lifecycle.preStop", scripts: dev.Lifecycle.PreStop},
+	}
+
+	for _, e := range events {
+		for _, name := range e.scripts {
+			if _, ok := dev.Scripts[name]; !ok {
+				errs = append(errs, dev.fieldErrorf(e.field, "Lifecycle script '%s' is not defined in scripts", name))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateDown checks that the down entries without arguments reference a script, since they can't be told apart from a typo
+func (dev *Dev) validateDown() []*FieldError {
+	var errs []*FieldError
+	for _, entry := range dev.Down {
+		if strings.TrimSpace(entry) == "" {
+			errs = append(errs, dev.fieldErrorf("down", "Down command cannot be empty"))
+			continue
+		}
+
+		if _, ok := dev.Scripts[entry]; ok || isInlineCommand(entry) {
+			continue
+		}
+
+		errs = append(errs, dev.fieldErrorf("down", "Down script '%s' is not defined in scripts", entry))
+	}
+
+	return errs
+}
+
+// DownCommands returns the commands run by "cnd down" before restoring the deployment, in order.
+// Each down entry is either the name of a script, or an inline command
+func (dev *Dev) DownCommands() []string {
+	commands := make([]string, 0, len(dev.Down))
+	for _, entry := range dev.Down {
+		if script, ok := dev.Scripts[entry]; ok {
+			commands = append(commands, script)
+			continue
+		}
+
+		commands = append(commands, entry)
+	}
+
+	return commands
+}
+
+// isInlineCommand returns true if a down entry has arguments, so it's not the name of a script
+func isInlineCommand(entry string) bool {
+	return len(strings.Fields(entry)) > 1
+}
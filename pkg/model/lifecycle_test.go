@@ -0,0 +1,138 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_validateLifecycle(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{
+			name: "valid",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+scripts:
+  install: npm install
+  migrate: npm run migrate
+  flush: npm run flush
+lifecycle:
+  postStart: [install, migrate]
+  preStop: [flush]`,
+		},
+		{
+			name: "missing-script",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+scripts:
+  install: npm install
+lifecycle:
+  postStart: [install]
+  preStop: [flush]`,
+			expected: "lifecycle.preStop (line 9, column 3): Lifecycle script 'flush' is not defined in scripts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := d.validateLifecycle()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid lifecycle was rejected: %s", errs[0])
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func Test_validateDown(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{
+			name: "valid",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+scripts:
+  flush: npm run flush
+down:
+  - flush
+  - rm -rf /tmp/cache`,
+		},
+		{
+			name: "missing-script",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+scripts:
+  install: npm install
+down: [flush]`,
+			expected: "down (line 7, column 1): Down script 'flush' is not defined in scripts",
+		},
+		{
+			name: "empty",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+down: [" "]`,
+			expected: "Down command cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errs := d.validateDown()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid down commands were rejected: %s", errs[0])
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func TestDownCommands(t *testing.T) {
+	dev := &Dev{
+		Scripts: map[string]string{"flush": "npm run flush"},
+		Down:    []string{"flush", "rm -rf /tmp/cache"},
+	}
+
+	expected := []string{"npm run flush", "rm -rf /tmp/cache"}
+	if commands := dev.DownCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("%v != %v", commands, expected)
+	}
+}
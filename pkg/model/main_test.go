@@ -0,0 +1,20 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TestMain points CND_HOME to a temporary folder, so the tests don't read the defaults of the user
+func TestMain(m *testing.M) {
+	home, err := ioutil.TempDir("", "cnd-home")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("CND_HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
@@ -0,0 +1,156 @@
+package model
+
+// Merge returns a new dev with the non-zero fields of override replacing the ones of dev.
+// Mounts are merged by position, and scripts, resources and environment variables by name
+func (dev *Dev) Merge(override *Dev) *Dev {
+	d := dev.DeepCopy()
+	if override == nil {
+		return d
+	}
+
+	o := override.DeepCopy()
+	mergeString(&d.Swap.Deployment.Name, o.Swap.Deployment.Name)
+	if len(o.Swap.Selector) > 0 {
+		d.Swap.Selector = o.Swap.Selector
+	}
+
+	mergeString(&d.Swap.Deployment.Container, o.Swap.Deployment.Container)
+	mergeString(&d.Swap.Deployment.Image, o.Swap.Deployment.Image)
+	mergeString(&d.Swap.Deployment.WorkDir, o.Swap.Deployment.WorkDir)
+	mergeString(&d.Swap.Deployment.Namespace, o.Swap.Deployment.Namespace)
+	if len(o.Swap.Deployment.Command) > 0 {
+		d.Swap.Deployment.Command = o.Swap.Deployment.Command
+	}
+
+	if len(o.Swap.Deployment.Args) > 0 {
+		d.Swap.Deployment.Args = o.Swap.Deployment.Args
+	}
+
+	if len(o.Swap.Deployment.ImagePullSecrets) > 0 {
+		d.Swap.Deployment.ImagePullSecrets = o.Swap.Deployment.ImagePullSecrets
+	}
+
+	if !o.Swap.Deployment.Capabilities.IsEmpty() {
+		d.Swap.Deployment.Capabilities = o.Swap.Deployment.Capabilities
+	}
+
+	if len(o.Swap.Deployment.Containers) > 0 {
+		d.Swap.Deployment.Containers = o.Swap.Deployment.Containers
+	}
+
+	d.Swap.Deployment.Resources.Requests = mergeMap(d.Swap.Deployment.Resources.Requests, o.Swap.Deployment.Resources.Requests)
+	d.Swap.Deployment.Resources.Limits = mergeMap(d.Swap.Deployment.Resources.Limits, o.Swap.Deployment.Resources.Limits)
+
+	for i, m := range o.Mounts {
+		if i >= len(d.Mounts) {
+			d.Mounts = append(d.Mounts, m)
+			continue
+		}
+
+		if m.IsRemote() {
+			d.Mounts[i].Source = ""
+			d.Mounts[i].RemoteSource = m.RemoteSource
+		} else if m.Source != "" {
+			d.Mounts[i].Source = m.Source
+			d.Mounts[i].RemoteSource = nil
+		}
+		mergeString(&d.Mounts[i].Target, m.Target)
+		if m.Enabled != nil {
+			d.Mounts[i].Enabled = m.Enabled
+		}
+
+		if m.ReadOnly {
+			d.Mounts[i].ReadOnly = true
+		}
+
+		if len(m.Ignore) > 0 {
+			d.Mounts[i].Ignore = m.Ignore
+		}
+	}
+
+	if o.Sync.IdleThreshold != 0 {
+		d.Sync.IdleThreshold = o.Sync.IdleThreshold
+	}
+
+	if o.Sync.OwnerUID != nil {
+		d.Sync.OwnerUID = o.Sync.OwnerUID
+	}
+
+	if o.Sync.OwnerGID != nil {
+		d.Sync.OwnerGID = o.Sync.OwnerGID
+	}
+
+	mergeString(&d.Sync.Mode, o.Sync.Mode)
+
+	d.Scripts = mergeMap(d.Scripts, o.Scripts)
+	mergeString(&d.Editor, o.Editor)
+	mergeString(&d.APIVersion, o.APIVersion)
+	mergeString(&d.SyncImage, o.SyncImage)
+	mergeString(&d.SyncVolumeSize, o.SyncVolumeSize)
+	if len(o.Ports) > 0 {
+		d.Ports = o.Ports
+	}
+
+	if len(o.Ignore) > 0 {
+		d.Ignore = o.Ignore
+	}
+
+	if len(o.Include) > 0 {
+		d.Include = o.Include
+	}
+
+	if len(o.Lifecycle.PostStart) > 0 {
+		d.Lifecycle.PostStart = o.Lifecycle.PostStart
+	}
+
+	if len(o.Lifecycle.PreStop) > 0 {
+		d.Lifecycle.PreStop = o.Lifecycle.PreStop
+	}
+
+	if len(o.Down) > 0 {
+		d.Down = o.Down
+	}
+
+	if o.Healthcheck != nil {
+		d.Healthcheck = o.Healthcheck
+	}
+
+	for _, e := range o.Environment {
+		d.Environment = mergeEnvVar(d.Environment, e)
+	}
+
+	return d
+}
+
+func mergeString(base *string, override string) {
+	if override != "" {
+		*base = override
+	}
+}
+
+func mergeMap(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+
+	if base == nil {
+		base = make(map[string]string, len(override))
+	}
+
+	for k, v := range override {
+		base[k] = v
+	}
+
+	return base
+}
+
+func mergeEnvVar(environment []EnvVar, e EnvVar) []EnvVar {
+	for i := range environment {
+		if environment[i].Name == e.Name {
+			environment[i] = e
+			return environment
+		}
+	}
+
+	return append(environment, e)
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Merge(t *testing.T) {
+	base := &Dev{
+		Swap: Swap{Deployment: Deployment{
+			Name:      "api",
+			Container: "app",
+			Image:     "okteto/api",
+			Command:   []string{"make", "run"},
+		}},
+		Mounts:      []Mount{{Source: "/team/api", Target: "/app"}},
+		Scripts:     map[string]string{"test": "make test", "lint": "make lint"},
+		Environment: []EnvVar{{Name: "DEBUG", Value: "false"}},
+	}
+
+	override := &Dev{
+		Mounts:      []Mount{{Source: "/home/me/api"}},
+		Scripts:     map[string]string{"test": "go test ./..."},
+		Environment: []EnvVar{{Name: "DEBUG", Value: "true"}, {Name: "PORT", Value: "8080"}},
+	}
+
+	result := base.Merge(override)
+	expected := &Dev{
+		Swap: Swap{Deployment: Deployment{
+			Name:      "api",
+			Container: "app",
+			Image:     "okteto/api",
+			Command:   []string{"make", "run"},
+		}},
+		Mounts:      []Mount{{Source: "/home/me/api", Target: "/app"}},
+		Scripts:     map[string]string{"test": "go test ./...", "lint": "make lint"},
+		Environment: []EnvVar{{Name: "DEBUG", Value: "true"}, {Name: "PORT", Value: "8080"}},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("%+v != %+v", result, expected)
+	}
+
+	if base.Mounts[0].Source != "/team/api" || base.Scripts["test"] != "make test" || base.Environment[0].Value != "false" {
+		t.Errorf("base was modified: %+v", base)
+	}
+
+	if !base.Merge(&Dev{}).Equal(base) {
+		t.Errorf("empty override changed the base")
+	}
+}
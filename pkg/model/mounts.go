@@ -0,0 +1,382 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// gitRootPrefix marks a mount source relative to the git repository root, e.g. //services/api
+	gitRootPrefix = "//"
+
+	// DefaultMountTarget is the target of the mounts that don't define one
+	DefaultMountTarget = "/src"
+
+	// defaultTargetEnv overrides DefaultMountTarget, e.g. to follow the conventions of an organization
+	defaultTargetEnv = "CND_DEFAULT_TARGET"
+)
+
+var (
+	// sourceStatAttempts and sourceStatBackoff retry the transient errors checking a mount source, e.g. on NFS.
+	// The backoff doubles after each attempt
+	sourceStatAttempts = 3
+	sourceStatBackoff  = 100 * time.Millisecond
+
+	// statFile checks the mount sources, it's replaced in tests
+	statFile = os.Stat
+)
+
+// defaultMountTarget returns the target of the mounts that don't define one, $CND_DEFAULT_TARGET or DefaultMountTarget
+func defaultMountTarget() string {
+	if target := os.Getenv(defaultTargetEnv); target != "" {
+		return target
+	}
+
+	return DefaultMountTarget
+}
+
+// mountPresence tells apart the mount fields absent from the manifest, which take their default, from the explicitly empty ones
+type mountPresence struct {
+	Source *string `json:"source" yaml:"source"`
+	Target *string `json:"target" yaml:"target"`
+}
+
+// presentMountFields returns the fields defined by the mounts of the manifest, in the order of dev.Mounts.
+// The manifest was already decoded, so the errors are ignored
+func presentMountFields(b []byte, asJSON bool) []mountPresence {
+	var raw struct {
+		Mounts []mountPresence `json:"mounts" yaml:"mounts"`
+		Mount  *mountPresence  `json:"mount" yaml:"mount"`
+	}
+
+	if asJSON {
+		json.Unmarshal(b, &raw)
+	} else {
+		yaml.Unmarshal(b, &raw)
+	}
+
+	if raw.Mount != nil {
+		return []mountPresence{*raw.Mount}
+	}
+
+	return raw.Mounts
+}
+
+// cleanTarget removes the trailing and duplicated slashes of a mount target, e.g. /app//src/ is /app/src.
+// An empty target is kept empty
+func cleanTarget(target string) string {
+	if target == "" {
+		return ""
+	}
+
+	return path.Clean(target)
+}
+
+// IsEnabled returns false when the mount is explicitly disabled, and no files are synched
+func (m Mount) IsEnabled() bool {
+	return m.Enabled == nil || *m.Enabled
+}
+
+// IsRemote returns true if the mount syncs a git ref instead of a local folder
+func (m Mount) IsRemote() bool {
+	return m.RemoteSource != nil
+}
+
+// EnabledMounts returns the mounts that are synched with the remote container
+func (dev *Dev) EnabledMounts() []Mount {
+	var mounts []Mount
+	for _, m := range dev.Mounts {
+		if m.IsEnabled() {
+			mounts = append(mounts, m)
+		}
+	}
+
+	return mounts
+}
+
+// MainMount returns the first enabled mount, or the first mount if all of them are disabled
+func (dev *Dev) MainMount() Mount {
+	if mounts := dev.EnabledMounts(); len(mounts) > 0 {
+		return mounts[0]
+	}
+
+	if len(dev.Mounts) > 0 {
+		return dev.Mounts[0]
+	}
+
+	return Mount{}
+}
+
+// GetWorkDir returns the working directory of the swapped container, the main mount target by default
+func (dev *Dev) GetWorkDir() string {
+	if dev.Swap.Deployment.WorkDir != "" {
+		return dev.Swap.Deployment.WorkDir
+	}
+
+	return dev.MainMount().Target
+}
+
+// IsSynched returns true if any of the mounts is synched with the remote container
+func (dev *Dev) IsSynched() bool {
+	return len(dev.EnabledMounts()) > 0
+}
+
+// validateMounts checks the mounts, and if checkSources is set, their absolute source folders on the local filesystem.
+// The relative sources are checked by validateSources once fixPath resolves them against the manifest folder
+func (dev *Dev) validateMounts(checkSources bool) []*FieldError {
+	var errs []*FieldError
+	targets := map[string]int{}
+	for i, m := range dev.Mounts {
+		if !m.IsEnabled() {
+			continue
+		}
+
+		if m.IsRemote() {
+			errs = append(errs, dev.validateRemoteSource(i, m)...)
+		} else if m.Source == "" {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "source"), "Mount source cannot be empty"))
+		} else if checkSources && filepath.IsAbs(m.Source) {
+			errs = append(errs, dev.validateSource(i, m)...)
+		}
+
+		if m.Target == "" {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "target"), "Mount target cannot be empty"))
+			continue
+		}
+
+		if !strings.HasPrefix(m.Target, "/") {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "target"), "Mount target must be an absolute path, got %q", m.Target))
+		}
+
+		if containsPath(m.Target, CNDSyncMountPath) {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "target"), "Mount target %s cannot be or contain the sync mount path %s", m.Target, CNDSyncMountPath))
+		}
+
+		if j, ok := targets[m.Target]; ok {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "target"), "Mount target %s is already used by mount %d", m.Target, j))
+		}
+		targets[m.Target] = i
+	}
+
+	return errs
+}
+
+// validateRemoteSource checks the remote source of the mount i, which can't be used with a local source.
+// Its folder is synched by the remote side, so nothing is checked on the local filesystem
+func (dev *Dev) validateRemoteSource(i int, m Mount) []*FieldError {
+	if m.Source != "" {
+		return []*FieldError{dev.fieldErrorf(dev.mountField(i, "remoteSource"), "Mount source and remoteSource cannot be used together")}
+	}
+
+	if m.RemoteSource.Repo == "" {
+		return []*FieldError{dev.fieldErrorf(dev.mountField(i, "remoteSource.repo"), "Mount remoteSource repo cannot be empty")}
+	}
+
+	return nil
+}
+
+// validateSource checks that the source folder of the mount i exists, is a readable directory, and is on a supported filesystem
+func (dev *Dev) validateSource(i int, m Mount) []*FieldError {
+	field := dev.mountField(i, "source")
+	file, err := statSource(m.Source)
+	if err != nil && os.IsNotExist(err) {
+		fe := dev.fieldErrorf(field, "Source mount folder %s does not exists", m.Source)
+		fe.kind = ErrSourceMissing
+		return []*FieldError{fe}
+	}
+
+	if err != nil {
+		return []*FieldError{dev.fieldErrorf(field, "Source mount folder %s cannot be checked: %s", m.Source, err)}
+	}
+
+	if !file.Mode().IsDir() {
+		return []*FieldError{dev.fieldErrorf(field, "Source mount folder is not a directory")}
+	}
+
+	if !isReadableDir(m.Source) {
+		return []*FieldError{dev.fieldErrorf(field, "Source mount folder %s is not readable", m.Source)}
+	}
+
+	if !Validation.AllowSpecialFilesystems {
+		if err := validateSourceFilesystem(m.Source); err != nil {
+			return []*FieldError{dev.fieldErrorf(field, "%s", err)}
+		}
+	}
+
+	return nil
+}
+
+// validateSources checks the source folders of the enabled local mounts, once fixPath resolves the relative ones
+func (dev *Dev) validateSources() []*FieldError {
+	var errs []*FieldError
+	for i, m := range dev.Mounts {
+		if m.IsEnabled() && !m.IsRemote() && m.Source != "" {
+			errs = append(errs, dev.validateSource(i, m)...)
+		}
+	}
+
+	return errs
+}
+
+// createMissingSources creates the missing source folders of the enabled mounts, resolved by fixPath, and checks them
+func (dev *Dev) createMissingSources() error {
+	var errs []*FieldError
+	for i, m := range dev.Mounts {
+		if !m.IsEnabled() || m.IsRemote() || m.Source == "" {
+			continue
+		}
+
+		if _, err := statSource(m.Source); err != nil && os.IsNotExist(err) {
+			if err := os.MkdirAll(m.Source, 0755); err != nil {
+				errs = append(errs, dev.fieldErrorf(dev.mountField(i, "source"), "Source mount folder %s cannot be created: %s", m.Source, err))
+				continue
+			}
+
+			log.Infof("created the source mount folder %s", m.Source)
+		}
+	}
+
+	errs = append(errs, dev.validateMounts(true)...)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// validateSourcesOutsideHome checks that the absolute mount sources don't contain the cnd home,
+// since its state would be synched into the container. Relative sources are checked once fixPath resolves them
+func (dev *Dev) validateSourcesOutsideHome() []*FieldError {
+	home, err := filepath.Abs(cndHomePath())
+	if err != nil {
+		return nil
+	}
+
+	var errs []*FieldError
+	for i, m := range dev.Mounts {
+		if !m.IsEnabled() || m.IsRemote() || !filepath.IsAbs(m.Source) {
+			continue
+		}
+
+		if containsFilePath(m.Source, home) {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "source"), "Source mount folder %s cannot be or contain the cnd home %s, its state would be synched into the container", m.Source, home))
+		}
+	}
+
+	return errs
+}
+
+// validateAbsoluteSources rejects the relative sources of the enabled mounts when Validation.AbsoluteSourcesOnly is set.
+// The manifest is validated before fixPath resolves them
+func (dev *Dev) validateAbsoluteSources() []*FieldError {
+	if !Validation.AbsoluteSourcesOnly {
+		return nil
+	}
+
+	var errs []*FieldError
+	for i, m := range dev.Mounts {
+		if m.IsEnabled() && !m.IsRemote() && !filepath.IsAbs(m.Source) {
+			errs = append(errs, dev.fieldErrorf(dev.mountField(i, "source"), "Source mount folder %s must be an absolute path", m.Source))
+		}
+	}
+
+	return errs
+}
+
+// containsFilePath is like containsPath, for local file paths
+func containsFilePath(p, child string) bool {
+	p = filepath.Clean(p)
+	child = filepath.Clean(child)
+	return p == child || strings.HasPrefix(child, strings.TrimSuffix(p, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// statSource stats a mount source, retrying the errors other than a missing source
+func statSource(source string) (os.FileInfo, error) {
+	backoff := sourceStatBackoff
+	for attempt := 1; ; attempt++ {
+		file, err := statFile(source)
+		if err == nil || os.IsNotExist(err) || attempt >= sourceStatAttempts {
+			return file, err
+		}
+
+		log.Debugf("failed to check the mount source %s, retrying: %s", source, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// containsPath returns true if p is equal to or a parent of the child path
+func containsPath(p, child string) bool {
+	p = path.Clean(p)
+	return p == child || strings.HasPrefix(child, strings.TrimSuffix(p, "/")+"/")
+}
+
+// isReadableDir returns true if the entries of the folder can be listed
+func isReadableDir(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return false
+	}
+
+	return true
+}
+
+// mountField returns the path of a field of a mount, as written in the manifest
+func (dev *Dev) mountField(i int, field string) string {
+	if _, ok := dev.positions["mount"]; ok && i == 0 {
+		return "mount." + field
+	}
+
+	return fmt.Sprintf("mounts[%d].%s", i, field)
+}
+
+// resolveGitRootSources resolves the mount sources relative to the root of the git repository of dir
+func (dev *Dev) resolveGitRootSources(dir string) error {
+	for i := range dev.Mounts {
+		source := dev.Mounts[i].Source
+		if !strings.HasPrefix(source, gitRootPrefix) {
+			continue
+		}
+
+		root, err := findGitRoot(dir)
+		if err != nil {
+			return fmt.Errorf("Mount source '%s' is relative to the git repository root: %s", source, err)
+		}
+
+		dev.Mounts[i].Source = filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(source, gitRootPrefix)))
+	}
+
+	return nil
+}
+
+// findGitRoot returns the nearest ancestor of dir with a .git entry, which is a file in worktrees and submodules
+func findGitRoot(dir string) (string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for current := start; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, nil
+		}
+
+		if filepath.Dir(current) == current {
+			return "", fmt.Errorf("'%s' is not in a git repository", start)
+		}
+	}
+}
@@ -0,0 +1,655 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_loadDevMounts(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - source: ./server
+    target: /app
+  - source: ./proto
+    target: /proto
+    readOnly: true`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Mount{{Source: "./server", Target: "/app"}, {Source: "./proto", Target: "/proto", ReadOnly: true}}
+	if !reflect.DeepEqual(d.Mounts, expected) {
+		t.Errorf("mounts were not parsed: %+v", d.Mounts)
+	}
+
+	if len(d.Deprecations()) != 0 {
+		t.Errorf("mounts is reported as deprecated: %+v", d.Deprecations())
+	}
+}
+
+func Test_loadDevMountDefaults(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected Mount
+		valid    bool
+	}{
+		{name: "no-mounts", manifest: "swap:\n  deployment: api", expected: Mount{Source: ".", Target: DefaultMountTarget}, valid: true},
+		{name: "absent", manifest: "swap:\n  deployment: api\nmounts:\n  - readOnly: true", expected: Mount{Source: ".", Target: DefaultMountTarget, ReadOnly: true}, valid: true},
+		{name: "empty-source", manifest: "swap:\n  deployment: api\nmounts:\n  - source: \"\"\n    target: /app", expected: Mount{Source: "", Target: "/app"}, valid: false},
+		{name: "empty-target", manifest: "swap:\n  deployment: api\nmounts:\n  - source: .\n    target: \"\"", expected: Mount{Source: ".", Target: ""}, valid: false},
+		{name: "singular", manifest: "swap:\n  deployment: api\nmount:\n  source: \"\"", expected: Mount{Source: "", Target: DefaultMountTarget}, valid: false},
+		{name: "json-absent", manifest: `{"swap": {"deployment": "api"}, "mounts": [{"target": "/app"}]}`, expected: Mount{Source: ".", Target: "/app"}, valid: true},
+		{name: "remote-source", manifest: "swap:\n  deployment: api\nmounts:\n  - remoteSource:\n      repo: https://github.com/okteto/cnd.git", expected: Mount{Target: DefaultMountTarget, RemoteSource: &RemoteSource{Repo: "https://github.com/okteto/cnd.git"}}, valid: true},
+		{name: "json-empty-source", manifest: `{"swap": {"deployment": "api"}, "mounts": [{"source": "", "target": "/app"}]}`, expected: Mount{Source: "", Target: "/app"}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(d.Mounts[0], tt.expected) {
+				t.Errorf("%+v != %+v", d.Mounts[0], tt.expected)
+			}
+
+			err = d.ValidateStructure()
+			if tt.valid && err != nil {
+				t.Errorf("the defaults were rejected: %s", err)
+			}
+
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "cannot be empty")) {
+				t.Errorf("the explicitly empty field was accepted: %v", err)
+			}
+		})
+	}
+}
+
+func Test_loadDevSingularMount(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mount:
+  target: /app`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Mounts) != 1 || d.Mounts[0].Source != "." || d.Mounts[0].Target != "/app" {
+		t.Errorf("singular mount was not parsed: %+v", d.Mounts)
+	}
+
+	if len(d.Deprecations()) != 1 || d.Deprecations()[0].Replacement != "mounts" {
+		t.Errorf("singular mount is not reported as deprecated: %+v", d.Deprecations())
+	}
+
+	_, err = LoadDev([]byte(`
+swap:
+  deployment:
+    name: deployment
+mount:
+  target: /app
+mounts:
+  - target: /src`))
+	if err == nil {
+		t.Errorf("mount and mounts were accepted together")
+	}
+}
+
+func Test_validateMounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"server", "proto"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := filepath.Join(dir, "server")
+	proto := filepath.Join(dir, "proto")
+
+	var tests = []struct {
+		name     string
+		mounts   []Mount
+		expected string
+	}{
+		{name: "valid", mounts: []Mount{{Source: server, Target: "/app"}, {Source: proto, Target: "/proto"}}},
+		{name: "missing-source", mounts: []Mount{{Source: server, Target: "/app"}, {Source: filepath.Join(dir, "missing"), Target: "/proto"}}, expected: "mounts[1].source"},
+		{name: "empty-target", mounts: []Mount{{Source: server, Target: "/app"}, {Source: proto}}, expected: "mounts[1].target"},
+		{name: "relative-target", mounts: []Mount{{Source: server, Target: "app"}}, expected: `must be an absolute path, got "app"`},
+		{name: "default-target", mounts: []Mount{{Source: server, Target: "/src"}}},
+		{name: "duplicated-target", mounts: []Mount{{Source: server, Target: "/app"}, {Source: proto, Target: "/app"}}, expected: "already used by mount 0"},
+		{name: "sync-target", mounts: []Mount{{Source: server, Target: "/var/cnd-sync"}}, expected: "cannot be or contain the sync mount path /var/cnd-sync"},
+		{name: "sync-parent-target", mounts: []Mount{{Source: server, Target: "/var/"}}, expected: "Mount target /var/ cannot be or contain"},
+		{name: "root-target", mounts: []Mount{{Source: server, Target: "/"}}, expected: "Mount target / cannot be or contain"},
+		{name: "sync-sibling-target", mounts: []Mount{{Source: server, Target: "/var/cnd-sync-app"}}},
+		{name: "remote-source", mounts: []Mount{{RemoteSource: &RemoteSource{Repo: "https://github.com/okteto/cnd.git", Ref: "master"}, Target: "/app"}}},
+		{name: "remote-and-local-source", mounts: []Mount{{Source: server, RemoteSource: &RemoteSource{Repo: "https://github.com/okteto/cnd.git"}, Target: "/app"}}, expected: "Mount source and remoteSource cannot be used together"},
+		{name: "no-source", mounts: []Mount{{Target: "/app"}}, expected: "Mount source cannot be empty"},
+		{name: "remote-without-repo", mounts: []Mount{{RemoteSource: &RemoteSource{Ref: "master"}, Target: "/app"}}, expected: "mounts[0].remoteSource.repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment"}}, Mounts: tt.mounts}
+			err := dev.validate()
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("valid mounts were rejected: %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("wrong error, expected '%s': %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func Test_validateUnreadableSource(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("folder permissions are not enforced")
+	}
+
+	dir, err := ioutil.TempDir("", "cnd-mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	if err := os.Mkdir(source, 0300); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment"}}, Mounts: []Mount{{Source: source, Target: "/app"}}}
+	err = dev.validate()
+	if err == nil || !strings.Contains(err.Error(), "is not readable") {
+		t.Errorf("unreadable source was accepted: %v", err)
+	}
+}
+
+func Test_validateUntraversableSource(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("folder permissions are not enforced")
+	}
+
+	dir, err := ioutil.TempDir("", "cnd-mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	parent := filepath.Join(dir, "parent")
+	if err := os.Mkdir(parent, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(parent, "source"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(parent, 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(parent, 0700)
+
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "deployment"}}, Mounts: []Mount{{Source: filepath.Join(parent, "source"), Target: "/app"}}}
+	err = dev.validate()
+	if err == nil || !strings.Contains(err.Error(), "cannot be checked") {
+		t.Errorf("untraversable source was accepted: %v", err)
+	}
+}
+
+func Test_ReadDevGitRootSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{".git", filepath.Join("services", "api"), filepath.Join("deploy", "api")} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := []byte(`
+swap:
+  deployment:
+    name: api
+mounts:
+  - source: //services/api
+    target: /app`)
+	devPath := filepath.Join(dir, "deploy", "api", "cnd.yml")
+	if err := ioutil.WriteFile(devPath, manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := filepath.Join(dir, "services", "api"); d.Mounts[0].Source != expected {
+		t.Errorf("%s != %s", d.Mounts[0].Source, expected)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadDev(devPath)
+	if err == nil || !strings.Contains(err.Error(), "git repository") {
+		t.Errorf("source outside of a git repository was accepted: %v", err)
+	}
+}
+
+func Test_ReadDevSourceContainsHome(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := filepath.Join(dir, "project", ".cnd")
+	defer os.Setenv("CND_HOME", os.Getenv("CND_HOME"))
+	os.Setenv("CND_HOME", home)
+
+	if err := os.MkdirAll(filepath.Join(dir, "project", "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name   string
+		source string
+		valid  bool
+	}{
+		{name: "relative-parent", source: ".", valid: false},
+		{name: "absolute-parent", source: dir, valid: false},
+		{name: "home", source: home, valid: false},
+		{name: "sibling", source: filepath.Join(dir, "project", "src"), valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devPath := filepath.Join(dir, "project", "cnd.yml")
+			manifest := "swap:\n  deployment:\n    name: api\nmounts:\n  - source: " + tt.source + "\n    target: /app"
+			if err := ioutil.WriteFile(devPath, []byte(manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ReadDev(devPath)
+			if tt.valid && err != nil {
+				t.Errorf("valid source was rejected: %s", err)
+			}
+
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "cnd home")) {
+				t.Errorf("source containing the cnd home was accepted: %v", err)
+			}
+		})
+	}
+}
+
+func Test_ReadDevAbsoluteSourcesOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	Validation.AbsoluteSourcesOnly = true
+	defer func() { Validation.AbsoluteSourcesOnly = false }()
+
+	var tests = []struct {
+		name   string
+		source string
+		valid  bool
+	}{
+		{name: "relative", source: "./src", valid: false},
+		{name: "default", source: "", valid: false},
+		{name: "absolute", source: dir, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devPath := filepath.Join(dir, "cnd.yml")
+			manifest := "swap:\n  deployment:\n    name: api\nmounts:\n  - target: /app"
+			if tt.source != "" {
+				manifest += "\n    source: " + tt.source
+			}
+
+			if err := ioutil.WriteFile(devPath, []byte(manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ReadDev(devPath)
+			if tt.valid && err != nil {
+				t.Errorf("absolute source was rejected: %s", err)
+			}
+
+			if !tt.valid {
+				source := tt.source
+				if source == "" {
+					source = "."
+				}
+
+				if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("Source mount folder %s must be an absolute path", source)) {
+					t.Errorf("relative source was accepted: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_ReadDevCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "src", "api")
+	devPath := filepath.Join(dir, "cnd.yml")
+	manifest := "swap:\n  deployment:\n    name: api\nmounts:\n  - source: " + source + "\n    target: /app"
+	if err := ioutil.WriteFile(devPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(devPath); !errors.Is(err, ErrSourceMissing) {
+		t.Fatalf("missing source was accepted: %v", err)
+	}
+
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Fatalf("ReadDev created the source folder: %v", err)
+	}
+
+	if _, err := ReadDevCreate(devPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		t.Errorf("the source folder wasn't created: %v", err)
+	}
+}
+
+func Test_ReadDevCreateRelativeSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := ioutil.TempDir("", "cnd-wd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(wd)
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(wd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previous)
+
+	devPath := filepath.Join(dir, "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte("swap:\n  deployment:\n    name: api\nmounts:\n  - source: ./api\n    target: /app"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ReadDevCreate(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "api")); err != nil || !info.IsDir() {
+		t.Errorf("the source folder wasn't created next to the manifest: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(wd, "api")); !os.IsNotExist(err) {
+		t.Errorf("the source folder was created in the working directory: %v", err)
+	}
+
+	if !strings.HasSuffix(d.Mounts[0].Source, "api") || !filepath.IsAbs(d.Mounts[0].Source) {
+		t.Errorf("wrong source: %s", d.Mounts[0].Source)
+	}
+}
+
+func Test_ReadDevRelativeSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-relative")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := ioutil.TempDir("", "cnd-wd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(wd)
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(wd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previous)
+
+	if err := os.Mkdir(filepath.Join(dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	devPath := filepath.Join(dir, "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte("swap:\n  deployment:\n    name: api\nmounts:\n  - source: ./api\n    target: /app\n  - source: ./web\n    target: /web"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Validate(devPath)
+	if err == nil || !strings.Contains(err.Error(), "mounts[1].source") || strings.Contains(err.Error(), "mounts[0].source") {
+		t.Errorf("the sources weren't checked against the manifest folder: %v", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(wd, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(devPath); err == nil {
+		t.Errorf("the source was checked against the working directory")
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(devPath); err != nil {
+		t.Errorf("the sources next to the manifest were rejected: %s", err)
+	}
+}
+
+func Test_statSourceRetries(t *testing.T) {
+	defer func(attempts int, backoff time.Duration) {
+		sourceStatAttempts = attempts
+		sourceStatBackoff = backoff
+		statFile = os.Stat
+	}(sourceStatAttempts, sourceStatBackoff)
+
+	sourceStatAttempts = 3
+	sourceStatBackoff = time.Millisecond
+
+	var tests = []struct {
+		name     string
+		errs     []error
+		calls    int
+		expected error
+	}{
+		{
+			name:  "ok",
+			errs:  []error{nil},
+			calls: 1,
+		},
+		{
+			name:  "transient",
+			errs:  []error{syscall.EIO, syscall.EIO, nil},
+			calls: 3,
+		},
+		{
+			name:     "persistent",
+			errs:     []error{syscall.EIO, syscall.EIO, syscall.EIO, nil},
+			calls:    3,
+			expected: syscall.EIO,
+		},
+		{
+			name:     "not-exist",
+			errs:     []error{os.ErrNotExist, nil},
+			calls:    1,
+			expected: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			statFile = func(name string) (os.FileInfo, error) {
+				err := tt.errs[calls]
+				calls++
+				if err != nil {
+					return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+				}
+
+				return os.Stat(os.TempDir())
+			}
+
+			_, err := statSource("/source")
+			if calls != tt.calls {
+				t.Errorf("%d != %d", calls, tt.calls)
+			}
+
+			if tt.expected == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if tt.expected != nil && (err == nil || err.(*os.PathError).Err != tt.expected) {
+				t.Errorf("%v != %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_defaultMountTarget(t *testing.T) {
+	defer os.Unsetenv(defaultTargetEnv)
+
+	var tests = []struct {
+		name     string
+		env      string
+		manifest string
+		expected string
+	}{
+		{
+			name:     "builtin",
+			manifest: "swap:\n  deployment:\n    name: deployment",
+			expected: DefaultMountTarget,
+		},
+		{
+			name:     "env",
+			env:      "/workspace",
+			manifest: "swap:\n  deployment:\n    name: deployment\nmounts:\n  - source: .",
+			expected: "/workspace",
+		},
+		{
+			name:     "manifest",
+			env:      "/workspace",
+			manifest: "swap:\n  deployment:\n    name: deployment\nmounts:\n  - target: /app",
+			expected: "/app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(defaultTargetEnv, tt.env)
+			d, err := LoadDev([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Mounts[0].Target != tt.expected {
+				t.Errorf("%s != %s", d.Mounts[0].Target, tt.expected)
+			}
+
+			if tt.name != "manifest" && NewDev().Mounts[0].Target != tt.expected {
+				t.Errorf("%s != %s", NewDev().Mounts[0].Target, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_loadDevCleanTarget(t *testing.T) {
+	var tests = []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{name: "trailing-slash", target: "/app/", expected: "/app"},
+		{name: "doubled-slashes", target: "/app//src", expected: "/app/src"},
+		{name: "root", target: "/", expected: "/"},
+		{name: "clean", target: "/app", expected: "/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadDev([]byte(fmt.Sprintf(`
+swap:
+  deployment:
+    name: deployment
+    container: app
+    containers:
+      - name: worker
+        target: %s
+mounts:
+  - source: .
+    target: %s`, tt.target, tt.target)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Mounts[0].Target != tt.expected {
+				t.Errorf("%s != %s", d.Mounts[0].Target, tt.expected)
+			}
+
+			if target := d.ContainerMounts("worker")[0].Target; target != tt.expected {
+				t.Errorf("%s != %s", target, tt.expected)
+			}
+		})
+	}
+
+	dev := &Dev{Mounts: []Mount{{Source: ".", Target: "/app//src/"}}}
+	if target := dev.ContainerMounts("app")[0].Target; target != "/app/src" {
+		t.Errorf("%s != /app/src", target)
+	}
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxNamespaceLength is the maximum length of a kubernetes namespace name
+	maxNamespaceLength = validation.DNS1123LabelMaxLength
+
+	hashSuffixLength = 8
+)
+
+var (
+	invalidDNSChars = regexp.MustCompile("[^a-z0-9-]+")
+)
+
+// EphemeralNamespace returns a valid namespace name for a temporary dev session, derived from the deployment name and a seed like a PR number or a git sha
+func (dev *Dev) EphemeralNamespace(seed string) string {
+	name := toDNS1123Label(fmt.Sprintf("%s-%s", dev.Swap.Deployment.Name, seed))
+	if name != "" && len(name) <= maxNamespaceLength && len(validation.IsDNS1123Label(name)) == 0 {
+		return name
+	}
+
+	hash := shortHash(dev.Swap.Deployment.Name + "/" + seed)
+	prefix := name
+	if len(prefix) > maxNamespaceLength-hashSuffixLength-1 {
+		prefix = strings.TrimRight(prefix[:maxNamespaceLength-hashSuffixLength-1], "-")
+	}
+
+	if prefix == "" {
+		return fmt.Sprintf("cnd-%s", hash)
+	}
+
+	return fmt.Sprintf("%s-%s", prefix, hash)
+}
+
+// toDNS1123Label lowercases the value and replaces the characters not allowed in a DNS-1123 label
+func toDNS1123Label(value string) string {
+	value = invalidDNSChars.ReplaceAllString(strings.ToLower(value), "-")
+	return strings.Trim(value, "-")
+}
+
+func shortHash(value string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))[:hashSuffixLength]
+}
+
+func (dev *Dev) validateNamespace() []*FieldError {
+	if dev.Swap.Deployment.Namespace == "" {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Label(dev.Swap.Deployment.Namespace); len(errs) > 0 {
+		return []*FieldError{dev.fieldErrorf("swap.deployment.namespace", "Swap deployment namespace '%s' is not valid: %s", dev.Swap.Deployment.Namespace, strings.Join(errs, ", "))}
+	}
+
+	return nil
+}
+
+func (dev *Dev) validateContainerName() []*FieldError {
+	if dev.Swap.Deployment.Container == "" {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Label(dev.Swap.Deployment.Container); len(errs) > 0 {
+		return []*FieldError{dev.fieldErrorf("swap.deployment.container", "Swap deployment container '%s' is not a valid kubernetes container name, it must have lowercase alphanumeric characters or '-': %s", dev.Swap.Deployment.Container, strings.Join(errs, ", "))}
+	}
+
+	return nil
+}
+
+// validateSelector checks that the deployment is swapped either by name or by selector, and that the selector labels are valid
+func (dev *Dev) validateSelector() []*FieldError {
+	if len(dev.Swap.Selector) == 0 {
+		if dev.Swap.Deployment.Name == "" {
+			return []*FieldError{dev.fieldErrorf("swap.deployment.name", "Swap deployment name cannot be empty")}
+		}
+
+		return nil
+	}
+
+	if dev.Swap.Deployment.Name != "" {
+		return []*FieldError{dev.fieldErrorf("swap.selector", "Swap selector and deployment name cannot be used together")}
+	}
+
+	keys := make([]string, 0, len(dev.Swap.Selector))
+	for k := range dev.Swap.Selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []*FieldError
+	for _, k := range keys {
+		if e := validation.IsQualifiedName(k); len(e) > 0 {
+			errs = append(errs, dev.fieldErrorf("swap.selector", "Swap selector label '%s' is not valid: %s", k, strings.Join(e, ", ")))
+		}
+
+		if e := validation.IsValidLabelValue(dev.Swap.Selector[k]); len(e) > 0 {
+			errs = append(errs, dev.fieldErrorf("swap.selector", "Swap selector value '%s' of the label '%s' is not valid: %s", dev.Swap.Selector[k], k, strings.Join(e, ", ")))
+		}
+	}
+
+	return errs
+}
+
+// ResolveSelector sets the deployment name from the deployments matching the selector, listed by the caller with its kubernetes client.
+// It fails unless exactly one deployment matches. Devs swapping the deployment by name are unchanged
+func (dev *Dev) ResolveSelector(deployments []string) error {
+	if len(dev.Swap.Selector) == 0 {
+		return nil
+	}
+
+	switch len(deployments) {
+	case 0:
+		return fmt.Errorf("no deployment matches the selector %s", dev.selectorString())
+	case 1:
+		dev.Swap.Deployment.Name = deployments[0]
+		dev.Swap.Selector = nil
+		return nil
+	default:
+		return fmt.Errorf("several deployments match the selector %s: %s", dev.selectorString(), strings.Join(deployments, ", "))
+	}
+}
+
+// selectorString returns the selector like kubectl, e.g. app=api,release=prod
+func (dev *Dev) selectorString() string {
+	labels := make([]string, 0, len(dev.Swap.Selector))
+	for k, v := range dev.Swap.Selector {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	return strings.Join(labels, ",")
+}
+
+// validateImagePullSecrets checks that the image pull secrets are valid kubernetes secret names
+func (dev *Dev) validateImagePullSecrets() []*FieldError {
+	var errs []*FieldError
+	for _, name := range dev.Swap.Deployment.ImagePullSecrets {
+		if e := validation.IsDNS1123Subdomain(name); len(e) > 0 {
+			errs = append(errs, dev.fieldErrorf("swap.deployment.imagePullSecrets", "Swap deployment image pull secret '%s' is not a valid kubernetes secret name: %s", name, strings.Join(e, ", ")))
+		}
+	}
+
+	return errs
+}
+
+// NormalizedContainer returns the container name lowercased and with the characters not allowed in a DNS-1123 label replaced,
+// so names derived from it are valid kubernetes names
+func (dev *Dev) NormalizedContainer() string {
+	return toDNS1123Label(dev.Swap.Deployment.Container)
+}
+
+// ValidateGeneratedNames checks that the kubernetes names derived from the dev are valid, before sending them to the API server
+func (dev *Dev) ValidateGeneratedNames() error {
+	var invalid []string
+	check := func(kind, name string, errs []string) {
+		if len(errs) > 0 {
+			invalid = append(invalid, fmt.Sprintf("%s '%s': %s", kind, name, strings.Join(errs, ", ")))
+		}
+	}
+
+	check("deployment name", dev.Swap.Deployment.Name, validation.IsDNS1123Subdomain(dev.Swap.Deployment.Name))
+	check("label value", dev.Swap.Deployment.Name, validation.IsValidLabelValue(dev.Swap.Deployment.Name))
+	if dev.Swap.Deployment.Container != "" {
+		check("container name", dev.Swap.Deployment.Container, validation.IsDNS1123Label(dev.Swap.Deployment.Container))
+	}
+
+	for _, c := range dev.Swap.Deployment.Containers {
+		check("container name", c.Name, validation.IsDNS1123Label(c.Name))
+	}
+
+	for _, r := range dev.TeardownChecklist() {
+		switch r.Type {
+		case ResourceContainer, ResourceInitContainer, ResourceVolume:
+			check(string(r.Type)+" name", r.Name, validation.IsDNS1123Label(r.Name))
+		case ResourceAnnotation, ResourceLabel:
+			check(string(r.Type)+" key", r.Name, validation.IsQualifiedName(r.Name))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid kubernetes names:\n%s", strings.Join(invalid, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func Test_EphemeralNamespace(t *testing.T) {
+	var tests = []struct {
+		name       string
+		deployment string
+		seed       string
+		expected   string
+	}{
+		{name: "simple", deployment: "api", seed: "42", expected: "api-42"},
+		{name: "invalid-chars", deployment: "My_API", seed: "PR#42", expected: "my-api-pr-42"},
+		{name: "long", deployment: strings.Repeat("a", 60), seed: "0123456789abcdef"},
+		{name: "empty", deployment: "", seed: "__"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: tt.deployment}}}
+			result := dev.EphemeralNamespace(tt.seed)
+			if errs := validation.IsDNS1123Label(result); len(errs) > 0 {
+				t.Fatalf("%s is not a valid namespace: %s", result, errs)
+			}
+
+			if tt.expected != "" && result != tt.expected {
+				t.Errorf("%s != %s", result, tt.expected)
+			}
+
+			if result != dev.EphemeralNamespace(tt.seed) {
+				t.Errorf("namespace is not deterministic")
+			}
+		})
+	}
+
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: strings.Repeat("a", 60)}}}
+	if dev.EphemeralNamespace("1") == dev.EphemeralNamespace("2") {
+		t.Errorf("truncated namespaces collide")
+	}
+}
+
+func Test_ValidateGeneratedNames(t *testing.T) {
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Container: "app"}}}
+	if err := dev.ValidateGeneratedNames(); err != nil {
+		t.Errorf("valid names were rejected: %s", err)
+	}
+
+	dev = &Dev{Swap: Swap{Deployment: Deployment{Name: "API", Container: "my_app"}}}
+	err := dev.ValidateGeneratedNames()
+	if err == nil {
+		t.Fatal("invalid names were accepted")
+	}
+
+	for _, expected := range []string{"deployment name 'API'", "container name 'my_app'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s is not reported: %s", expected, err)
+		}
+	}
+}
+
+func Test_validateNamespace(t *testing.T) {
+	var tests = []struct {
+		name      string
+		namespace string
+		valid     bool
+	}{
+		{name: "empty", namespace: "", valid: true},
+		{name: "valid", namespace: "team-dev", valid: true},
+		{name: "uppercase", namespace: "Team", valid: false},
+		{name: "dots", namespace: "team.dev", valid: false},
+		{name: "too-long", namespace: strings.Repeat("a", 64), valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Namespace: tt.namespace}}}
+			errs := dev.validateNamespace()
+			if tt.valid && len(errs) > 0 {
+				t.Errorf("valid namespace was rejected: %s", errs[0])
+			}
+
+			if !tt.valid && len(errs) == 0 {
+				t.Errorf("invalid namespace '%s' was accepted", tt.namespace)
+			}
+		})
+	}
+}
+
+func Test_validateContainerName(t *testing.T) {
+	var tests = []struct {
+		name       string
+		container  string
+		valid      bool
+		normalized string
+	}{
+		{name: "empty", container: "", valid: true, normalized: ""},
+		{name: "valid", container: "api-server", valid: true, normalized: "api-server"},
+		{name: "underscore", container: "api_server", valid: false, normalized: "api-server"},
+		{name: "uppercase", container: "ApiServer", valid: false, normalized: "apiserver"},
+		{name: "dots", container: "api.server", valid: false, normalized: "api-server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Container: tt.container}}}
+			errs := dev.validateContainerName()
+			if tt.valid && len(errs) > 0 {
+				t.Errorf("valid container was rejected: %s", errs[0])
+			}
+
+			if !tt.valid && len(errs) == 0 {
+				t.Errorf("invalid container '%s' was accepted", tt.container)
+			}
+
+			if n := dev.NormalizedContainer(); n != tt.normalized {
+				t.Errorf("%s != %s", n, tt.normalized)
+			}
+		})
+	}
+}
+
+func Test_validateImagePullSecrets(t *testing.T) {
+	var tests = []struct {
+		name    string
+		secrets []string
+		valid   bool
+	}{
+		{name: "empty", secrets: nil, valid: true},
+		{name: "valid", secrets: []string{"registry", "gcr.io-credentials"}, valid: true},
+		{name: "uppercase", secrets: []string{"Registry"}, valid: false},
+		{name: "empty-name", secrets: []string{""}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", ImagePullSecrets: tt.secrets}}}
+			errs := dev.validateImagePullSecrets()
+			if tt.valid && len(errs) > 0 {
+				t.Errorf("valid secrets were rejected: %s", errs[0])
+			}
+
+			if !tt.valid && len(errs) == 0 {
+				t.Errorf("invalid secrets %v were accepted", tt.secrets)
+			}
+		})
+	}
+}
+
+func Test_validateSelector(t *testing.T) {
+	var tests = []struct {
+		name       string
+		deployment string
+		selector   map[string]string
+		expected   string
+	}{
+		{name: "name", deployment: "api"},
+		{name: "selector", selector: map[string]string{"app": "api", "app.kubernetes.io/instance": "prod"}},
+		{name: "none", expected: "Swap deployment name cannot be empty"},
+		{name: "both", deployment: "api", selector: map[string]string{"app": "api"}, expected: "Swap selector and deployment name cannot be used together"},
+		{name: "invalid-label", selector: map[string]string{"my app": "api"}, expected: "Swap selector label 'my app' is not valid"},
+		{name: "invalid-value", selector: map[string]string{"app": "my api"}, expected: "Swap selector value 'my api' of the label 'app' is not valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: tt.deployment}, Selector: tt.selector}}
+			errs := dev.validateSelector()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid swap was rejected: %v", errs)
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func Test_ResolveSelector(t *testing.T) {
+	dev := &Dev{Swap: Swap{Selector: map[string]string{"release": "prod", "app": "api"}}}
+	if err := dev.ResolveSelector(nil); err == nil || !strings.Contains(err.Error(), "app=api,release=prod") {
+		t.Errorf("no deployments were resolved: %v", err)
+	}
+
+	if err := dev.ResolveSelector([]string{"api-1", "api-2"}); err == nil || !strings.Contains(err.Error(), "api-1, api-2") {
+		t.Errorf("several deployments were resolved: %v", err)
+	}
+
+	if err := dev.ResolveSelector([]string{"api-7f9c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.Swap.Deployment.Name != "api-7f9c" || dev.Swap.Selector != nil {
+		t.Errorf("the deployment wasn't resolved: %+v", dev.Swap)
+	}
+
+	if err := dev.validateSelector(); len(err) > 0 {
+		t.Errorf("the resolved dev is not valid: %v", err)
+	}
+}
@@ -7,12 +7,21 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// GetCNDHome returns the base path for CND config files
+// GetCNDHome returns the base path for CND config files, $CND_HOME or $HOME/.cnd by default. It's created if it doesn't exist
 func GetCNDHome() string {
-	home := path.Join(os.Getenv("HOME"), ".cnd")
+	home := cndHomePath()
 	if err := os.MkdirAll(home, 0700); err != nil {
 		log.Errorf("failed to create the home directory: %s", err)
 	}
 
 	return home
 }
+
+// cndHomePath returns the path of GetCNDHome without creating it, e.g. to read a manifest without side effects
+func cndHomePath() string {
+	if home := os.Getenv("CND_HOME"); home != "" {
+		return home
+	}
+
+	return path.Join(os.Getenv("HOME"), ".cnd")
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_GetCNDHome(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := filepath.Join(dir, "home")
+	defer os.Setenv("CND_HOME", os.Getenv("CND_HOME"))
+	os.Setenv("CND_HOME", home)
+
+	if GetCNDHome() != home {
+		t.Errorf("%s != %s", GetCNDHome(), home)
+	}
+
+	if _, err := os.Stat(home); err != nil {
+		t.Errorf("home wasn't created: %s", err)
+	}
+}
+
+func Test_ReadDevKeepsCNDHome(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := filepath.Join(dir, "home")
+	defer os.Setenv("CND_HOME", os.Getenv("CND_HOME"))
+	os.Setenv("CND_HOME", home)
+
+	if err := os.Mkdir(filepath.Join(dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	devPath := filepath.Join(dir, "api", "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte("swap:\n  deployment:\n    name: api\nmounts:\n  - source: .\n    target: /app"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadDev(devPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Validate(devPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(home); !os.IsNotExist(err) {
+		t.Errorf("home was created reading the manifest: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortForward is a container port forwarded to a local port
+type PortForward struct {
+	Local  int
+	Remote int
+}
+
+// GetPortForwards returns the parsed forward entries of the dev, skipping the malformed ones
+func (dev *Dev) GetPortForwards() []PortForward {
+	var forwards []PortForward
+	for _, p := range dev.Ports {
+		if f, err := parsePortForward(p); err == nil {
+			forwards = append(forwards, f)
+		}
+	}
+
+	return forwards
+}
+
+// parsePortForward parses a forward entry, either 'port' or 'local:remote'
+func parsePortForward(s string) (PortForward, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 2 {
+		return PortForward{}, fmt.Errorf("'%s' must be 'port' or 'local:remote'", s)
+	}
+
+	ports := make([]int, len(parts))
+	for i, p := range parts {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return PortForward{}, fmt.Errorf("'%s' is not a valid port", p)
+		}
+
+		if port < 1 || port > 65535 {
+			return PortForward{}, fmt.Errorf("port %d must be between 1 and 65535", port)
+		}
+
+		ports[i] = port
+	}
+
+	return PortForward{Local: ports[0], Remote: ports[len(ports)-1]}, nil
+}
+
+func (dev *Dev) validatePorts() []*FieldError {
+	var errs []*FieldError
+	locals := map[int]bool{}
+	for i, p := range dev.Ports {
+		f, err := parsePortForward(p)
+		if err != nil {
+			errs = append(errs, dev.fieldErrorf("forward", "Forward element %d is invalid: %s", i, err))
+			continue
+		}
+
+		if locals[f.Local] {
+			errs = append(errs, dev.fieldErrorf("forward", "Local port %d is forwarded more than once", f.Local))
+		}
+		locals[f.Local] = true
+	}
+
+	return errs
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parsePortForward(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		expected PortForward
+		valid    bool
+	}{
+		{name: "single", value: "5000", expected: PortForward{Local: 5000, Remote: 5000}, valid: true},
+		{name: "mapping", value: "8080:80", expected: PortForward{Local: 8080, Remote: 80}, valid: true},
+		{name: "zero", value: "0", valid: false},
+		{name: "out-of-range", value: "8080:65536", valid: false},
+		{name: "not-a-number", value: "http", valid: false},
+		{name: "too-many-parts", value: "1:2:3", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parsePortForward(tt.value)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("'%s' was accepted", tt.value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f != tt.expected {
+				t.Errorf("%+v != %+v", f, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_validatePorts(t *testing.T) {
+	dev := &Dev{Ports: []string{"8080:8080", "5000"}}
+	if errs := dev.validatePorts(); len(errs) != 0 {
+		t.Errorf("valid ports were rejected: %v", errs)
+	}
+
+	expected := []PortForward{{Local: 8080, Remote: 8080}, {Local: 5000, Remote: 5000}}
+	if !reflect.DeepEqual(dev.GetPortForwards(), expected) {
+		t.Errorf("%+v != %+v", dev.GetPortForwards(), expected)
+	}
+
+	dev = &Dev{Ports: []string{"8080:80", "8080:8080"}}
+	errs := dev.validatePorts()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "8080") {
+		t.Errorf("duplicated local port was accepted: %v", errs)
+	}
+}
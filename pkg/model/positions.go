@@ -0,0 +1,138 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	yamlKeyRegexp = regexp.MustCompile(`^(\s*)([A-Za-z0-9_.-]+)\s*:(\s|$)`)
+)
+
+// position is the location of a field in the manifest
+type position struct {
+	line   int
+	column int
+}
+
+// FieldError is a validation error caused by a specific field of the manifest
+type FieldError struct {
+	// Field is the path of the field, e.g. swap.deployment.name
+	Field string
+
+	// Line and Column locate the field in the manifest. They are zero when the position is unknown
+	Line   int
+	Column int
+
+	Message string
+
+	// kind is the sentinel error matched by errors.Is, if any
+	kind error
+}
+
+func (e *FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s (line %d, column %d): %s", e.Field, e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Is returns true if target is the kind of the field error, e.g. ErrSourceMissing
+func (e *FieldError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+// ValidationError is the list of problems found when validating a dev
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Is returns true if target is ErrInvalidManifest, or the kind of any of the field errors
+func (e *ValidationError) Is(target error) bool {
+	if target == ErrInvalidManifest {
+		return true
+	}
+
+	for _, fe := range e.Errors {
+		if fe.Is(target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldErrorf returns a FieldError located at the field, or at its closest parent defined in the manifest
+func (dev *Dev) fieldErrorf(field, format string, a ...interface{}) *FieldError {
+	e := &FieldError{Field: field, Message: fmt.Sprintf(format, a...)}
+	for f := field; f != ""; f = parentField(f) {
+		if p, ok := dev.positions[f]; ok {
+			e.Line = p.line
+			e.Column = p.column
+			break
+		}
+	}
+
+	return e
+}
+
+func parentField(field string) string {
+	i := strings.LastIndex(field, ".")
+	if i < 0 {
+		return ""
+	}
+
+	return field[:i]
+}
+
+// getFieldPositions is a lightweight side parse of a block style yaml manifest that returns the position of each mapping key.
+// Flow style mappings and the keys inside sequences are not located.
+func getFieldPositions(b []byte) map[string]position {
+	type key struct {
+		indent int
+		name   string
+	}
+
+	positions := map[string]position{}
+	stack := []key{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	line := 0
+	for scanner.Scan() {
+		line++
+		m := yamlKeyRegexp.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		indent := len(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, key{indent: indent, name: m[2]})
+
+		names := make([]string, len(stack))
+		for i, k := range stack {
+			names[i] = k.name
+		}
+
+		field := strings.Join(names, ".")
+		if _, ok := positions[field]; !ok {
+			positions[field] = position{line: line, column: indent + 1}
+		}
+	}
+
+	return positions
+}
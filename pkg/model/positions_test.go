@@ -0,0 +1,95 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_getFieldPositions(t *testing.T) {
+	manifest := []byte(`# comment
+swap:
+  deployment:
+    name: deployment
+    command:
+      - uwsgi
+mount:
+  source: .
+  target: /app`)
+
+	positions := getFieldPositions(manifest)
+	expected := map[string]position{
+		"swap":                    {line: 2, column: 1},
+		"swap.deployment":         {line: 3, column: 3},
+		"swap.deployment.name":    {line: 4, column: 5},
+		"swap.deployment.command": {line: 5, column: 5},
+		"mount":                   {line: 7, column: 1},
+		"mount.source":            {line: 8, column: 3},
+		"mount.target":            {line: 9, column: 3},
+	}
+
+	for field, p := range expected {
+		if positions[field] != p {
+			t.Errorf("%s: %+v != %+v", field, positions[field], p)
+		}
+	}
+}
+
+func Test_fieldErrorPosition(t *testing.T) {
+	d, err := LoadDev([]byte(`
+swap:
+  deployment:
+    container: api
+mount:
+  source: .`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.validate()
+	if err == nil {
+		t.Fatal("empty deployment name was accepted")
+	}
+
+	ve, ok := err.(*ValidationError)
+	if !ok || len(ve.Errors) != 1 {
+		t.Fatalf("not a single validation error: %s", err)
+	}
+
+	fe := ve.Errors[0]
+	if fe.Field != "swap.deployment.name" || fe.Line != 3 || fe.Column != 3 {
+		t.Errorf("wrong position: %+v", fe)
+	}
+
+	d = &Dev{Mounts: []Mount{{Source: ".", Target: "/app"}}}
+	if err := d.validate(); err.Error() != "swap.deployment.name: Swap deployment name cannot be empty" {
+		t.Errorf("wrong error without positions: %s", err)
+	}
+}
+
+func Test_validateCollectsErrors(t *testing.T) {
+	d := &Dev{
+		Swap:   Swap{Deployment: Deployment{Command: []string{""}}},
+		Mounts: []Mount{{Source: "/does/not/exist", Target: "app"}},
+	}
+
+	err := d.validate()
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("not a validation error: %s", err)
+	}
+
+	expected := []string{"mounts[0].source", "mounts[0].target", "swap.deployment.name", "swap.deployment.command"}
+	if len(ve.Errors) != len(expected) {
+		t.Fatalf("wrong number of errors: %s", err)
+	}
+
+	for i, field := range expected {
+		if ve.Errors[i].Field != field {
+			t.Errorf("%s != %s", ve.Errors[i].Field, field)
+		}
+	}
+
+	if len(strings.Split(err.Error(), "\n")) != len(expected) {
+		t.Errorf("errors are not one per line: %s", err)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceRequirements are the compute resources requested by and limited to the dev container
+type ResourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// IsEmpty returns true when no requests or limits are declared
+func (r ResourceRequirements) IsEmpty() bool {
+	return len(r.Requests) == 0 && len(r.Limits) == 0
+}
+
+func (r ResourceRequirements) validate() error {
+	for _, list := range []map[string]string{r.Requests, r.Limits} {
+		for name, quantity := range list {
+			if _, err := resource.ParseQuantity(quantity); err != nil {
+				return fmt.Errorf("'%s' is not a valid quantity for '%s'", quantity, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_validateResourceRequirements(t *testing.T) {
+	var tests = []struct {
+		name      string
+		resources ResourceRequirements
+		valid     bool
+	}{
+		{name: "empty", resources: ResourceRequirements{}, valid: true},
+		{name: "valid", resources: ResourceRequirements{Requests: map[string]string{"cpu": "250m", "memory": "256Mi"}, Limits: map[string]string{"memory": "1Gi"}}, valid: true},
+		{name: "malformed-request", resources: ResourceRequirements{Requests: map[string]string{"cpu": "lots"}}, valid: false},
+		{name: "malformed-limit", resources: ResourceRequirements{Limits: map[string]string{"memory": "1 GB"}}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.resources.validate()
+			if tt.valid && err != nil {
+				t.Errorf("valid resources were rejected: %s", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Errorf("malformed resources were accepted")
+			}
+		})
+	}
+}
+
+func Test_validateSyncVolumeSize(t *testing.T) {
+	var tests = []struct {
+		name  string
+		size  string
+		valid bool
+	}{
+		{name: "empty", size: "", valid: true},
+		{name: "valid", size: "10Gi", valid: true},
+		{name: "malformed", size: "10 GB", valid: false},
+		{name: "zero", size: "0", valid: false},
+		{name: "negative", size: "-1Gi", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api"}}, SyncVolumeSize: tt.size}
+			err := dev.ValidateStructure()
+			if tt.valid && err != nil {
+				t.Errorf("valid size was rejected: %s", err)
+			}
+
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), "syncVolumeSize")) {
+				t.Errorf("malformed size was accepted: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// MaxAnnotationSize is the limit of kubernetes for the total size of the annotations of an object
+const MaxAnnotationSize = 256 * 1024
+
+// ResourceType is the kind of a kubernetes resource created by cnd
+type ResourceType string
+
+const (
+	// ResourceContainer is a container added to the deployment
+	ResourceContainer ResourceType = "container"
+
+	// ResourceInitContainer is an init container added to the deployment
+	ResourceInitContainer ResourceType = "initContainer"
+
+	// ResourceVolume is a volume added to the deployment
+	ResourceVolume ResourceType = "volume"
+
+	// ResourceAnnotation is an annotation added to the deployment
+	ResourceAnnotation ResourceType = "annotation"
+
+	// ResourceLabel is a label added to the deployment and its pods
+	ResourceLabel ResourceType = "label"
+)
+
+// Resource is a kubernetes resource created by cnd
+type Resource struct {
+	Type ResourceType
+	Name string
+}
+
+// SyncNames are the names of the kubernetes objects created to synchronize the files of a dev
+type SyncNames struct {
+	Volume             string
+	Mount              string
+	InitContainer      string
+	Container          string
+	ManifestAnnotation string
+}
+
+// Names returns the names of the sync objects of the dev. They're fixed, not derived per container: the containers
+// of swap.deployment.containers share the sync objects of the main container, and activating a dev on a deployment
+// already swapped by a dev of another container fails, since the original manifest annotation is kept until cnd down
+func (dev *Dev) Names() SyncNames {
+	return SyncNames{
+		Volume:             CNDSyncVolumeName,
+		Mount:              CNDSyncMountPath,
+		InitContainer:      CNDInitSyncContainerName,
+		Container:          CNDSyncContainerName,
+		ManifestAnnotation: CNDDeploymentAnnotation,
+	}
+}
+
+// Annotations returns every annotation that cnd sets on the deployment when the dev is activated, with their values.
+// The dev is serialized as json, so GetDevFromAnnotation can read it back, and manifest is the original deployment,
+// encoded with EncodeManifestAnnotation
+func (dev *Dev) Annotations(manifest []byte) (map[string]string, error) {
+	b, err := json.Marshal(dev)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := EncodeManifestAnnotation(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		CNDDevAnnotation:               string(b),
+		dev.Names().ManifestAnnotation: encoded,
+	}, nil
+}
+
+// EncodeManifestAnnotation returns the value of the annotation with the original deployment manifest, gzipped and base64 encoded.
+// It fails if the value exceeds MaxAnnotationSize
+func EncodeManifestAnnotation(manifest []byte) (string, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(manifest); err != nil {
+		return "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	value := base64.StdEncoding.EncodeToString(b.Bytes())
+	if len(value) > MaxAnnotationSize {
+		return "", fmt.Errorf("the deployment manifest is %d bytes once encoded, it exceeds the annotation limit of %d bytes", len(value), MaxAnnotationSize)
+	}
+
+	return value, nil
+}
+
+// DecodeManifestAnnotation returns the original deployment manifest of the annotation value.
+// The values written as plain json by older versions of cnd are returned as they are
+func DecodeManifestAnnotation(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return []byte(value), nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("the deployment manifest annotation is not valid: %s", err)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("the deployment manifest annotation is not valid: %s", err)
+	}
+	defer r.Close()
+
+	manifest, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("the deployment manifest annotation is not valid: %s", err)
+	}
+
+	return manifest, nil
+}
+
+// TeardownChecklist returns every resource created when the dev is activated, so teardown can verify they are gone
+func (dev *Dev) TeardownChecklist() []Resource {
+	names := dev.Names()
+	resources := []Resource{
+		{Type: ResourceAnnotation, Name: names.ManifestAnnotation},
+		{Type: ResourceAnnotation, Name: CNDDevAnnotation},
+		{Type: ResourceLabel, Name: CNDLabel},
+	}
+
+	if !dev.IsSynched() {
+		return resources
+	}
+
+	return append(
+		resources,
+		Resource{Type: ResourceInitContainer, Name: names.InitContainer},
+		Resource{Type: ResourceContainer, Name: names.Container},
+		Resource{Type: ResourceVolume, Name: names.Volume},
+	)
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_TeardownChecklist(t *testing.T) {
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Container: "app"}}, Mounts: []Mount{{Source: ".", Target: "/app"}}}
+	checklist := dev.TeardownChecklist()
+
+	expected := map[Resource]bool{
+		{Type: ResourceInitContainer, Name: CNDInitSyncContainerName}: true,
+		{Type: ResourceContainer, Name: CNDSyncContainerName}:         true,
+		{Type: ResourceVolume, Name: CNDSyncVolumeName}:               true,
+		{Type: ResourceAnnotation, Name: CNDDevAnnotation}:            true,
+		{Type: ResourceLabel, Name: CNDLabel}:                         true,
+	}
+
+	found := map[Resource]bool{}
+	for _, r := range checklist {
+		found[r] = true
+	}
+
+	for r := range expected {
+		if !found[r] {
+			t.Errorf("%+v is not in the checklist", r)
+		}
+	}
+}
+
+func Test_Names(t *testing.T) {
+	dev := &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Container: "app"}}}
+	expected := SyncNames{
+		Volume:             "cnd-sync",
+		Mount:              "/var/cnd-sync",
+		InitContainer:      "cnd-init-syncthing",
+		Container:          "cnd-syncthing",
+		ManifestAnnotation: "cnd.okteto.com/deployment",
+	}
+
+	if names := dev.Names(); names != expected {
+		t.Errorf("%+v != %+v", names, expected)
+	}
+}
+
+func Test_Annotations(t *testing.T) {
+	dev := &Dev{
+		Swap:    Swap{Deployment: Deployment{Name: "api", Container: "app", Image: "okteto/api:1.0", Command: []string{"make", "run"}}},
+		Mounts:  []Mount{{Source: "/home/cnd/api", Target: "/app"}},
+		Scripts: map[string]string{"test": "make test"},
+	}
+
+	manifest := []byte(`{"metadata":{"name":"api"},"spec":{"replicas":1}}`)
+	annotations, err := dev.Annotations(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(annotations) != 2 {
+		t.Errorf("wrong annotations: %v", annotations)
+	}
+
+	for k := range annotations {
+		if !strings.HasPrefix(k, "cnd.okteto.com/") {
+			t.Errorf("wrong annotation key: %s", k)
+		}
+	}
+
+	decoded, err := DecodeManifestAnnotation(annotations[CNDDeploymentAnnotation])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decoded, manifest) {
+		t.Errorf("%s != %s", decoded, manifest)
+	}
+
+	var result Dev
+	if err := json.Unmarshal([]byte(annotations[CNDDevAnnotation]), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Equal(dev) {
+		t.Errorf("%+v != %+v", result, dev)
+	}
+}
+
+func Test_ManifestAnnotation(t *testing.T) {
+	manifest := []byte(`{"metadata":{"name":"api"},"spec":{"replicas":1}}`)
+	encoded, err := EncodeManifestAnnotation(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeManifestAnnotation(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decoded, manifest) {
+		t.Errorf("%s != %s", decoded, manifest)
+	}
+
+	decoded, err = DecodeManifestAnnotation(string(manifest))
+	if err != nil || !bytes.Equal(decoded, manifest) {
+		t.Errorf("the plain json manifest wasn't decoded: %s %v", decoded, err)
+	}
+
+	if _, err := DecodeManifestAnnotation("not-a-manifest"); err == nil {
+		t.Errorf("an invalid annotation was decoded")
+	}
+
+	large := make([]byte, MaxAnnotationSize)
+	if _, err := rand.Read(large); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := EncodeManifestAnnotation(large); err == nil {
+		t.Errorf("a manifest over the annotation limit was encoded")
+	}
+}
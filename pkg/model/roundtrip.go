@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RoundTripOK checks that marshalling the dev to yaml and back doesn't lose or change any field
+func (dev *Dev) RoundTripOK() error {
+	b, err := yaml.Marshal(dev)
+	if err != nil {
+		return fmt.Errorf("error marshalling the dev: %s", err)
+	}
+
+	var result Dev
+	if err := yaml.Unmarshal(b, &result); err != nil {
+		return fmt.Errorf("error unmarshalling the dev: %s", err)
+	}
+
+	original := dev.normalized()
+	roundTrip := result.normalized()
+
+	areas := []struct {
+		name     string
+		original interface{}
+		result   interface{}
+	}{
+		{"swap", original.Swap, roundTrip.Swap},
+		{"mounts", original.Mounts, roundTrip.Mounts},
+		{"sync", original.Sync, roundTrip.Sync},
+		{"scripts", original.Scripts, roundTrip.Scripts},
+		{"editor", original.Editor, roundTrip.Editor},
+	}
+
+	var discrepancies []string
+	for _, a := range areas {
+		if !reflect.DeepEqual(a.original, a.result) {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: %+v != %+v", a.name, a.original, a.result))
+		}
+	}
+
+	if !reflect.DeepEqual(original, roundTrip) && len(discrepancies) == 0 {
+		discrepancies = append(discrepancies, fmt.Sprintf("%+v != %+v", original, roundTrip))
+	}
+
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("the dev changed after a yaml round trip:\n%s", strings.Join(discrepancies, "\n"))
+	}
+
+	return nil
+}
+
+// normalized returns a copy of the dev where empty slices and maps are nil, defaults are explicit and the loading metadata is cleared
+func (dev *Dev) normalized() *Dev {
+	d := dev.DeepCopy()
+	if d.APIVersion == "" {
+		d.APIVersion = CurrentAPIVersion
+	}
+
+	d.positions = nil
+	d.scriptFiles = nil
+	d.deprecations = nil
+	d.manifestPath = ""
+	normalizeStrings((*[]string)(&d.Swap.Deployment.Command))
+	normalizeStrings(&d.Swap.Deployment.Args)
+	normalizeStrings(&d.Swap.Deployment.ImagePullSecrets)
+	normalizeStrings(&d.Swap.Deployment.Capabilities.Add)
+	normalizeStrings(&d.Swap.Deployment.Capabilities.Drop)
+	normalizeStrings(&d.Ports)
+	normalizeStrings(&d.Ignore)
+	normalizeStrings(&d.Include)
+	normalizeStrings(&d.Lifecycle.PostStart)
+	normalizeStrings(&d.Lifecycle.PreStop)
+	normalizeStrings(&d.Down)
+	if d.Healthcheck != nil {
+		normalizeStrings((*[]string)(&d.Healthcheck.Command))
+	}
+
+	for i := range d.Mounts {
+		normalizeStrings(&d.Mounts[i].Ignore)
+	}
+
+	if len(d.Swap.Deployment.Resources.Requests) == 0 {
+		d.Swap.Deployment.Resources.Requests = nil
+	}
+
+	if len(d.Swap.Deployment.Resources.Limits) == 0 {
+		d.Swap.Deployment.Resources.Limits = nil
+	}
+
+	if len(d.Swap.Selector) == 0 {
+		d.Swap.Selector = nil
+	}
+
+	if len(d.Swap.Deployment.Containers) == 0 {
+		d.Swap.Deployment.Containers = nil
+	}
+
+	// the main mount target is the default workdir
+	d.Swap.Deployment.WorkDir = d.GetWorkDir()
+
+	for i := range d.Swap.Deployment.Containers {
+		normalizeStrings((*[]string)(&d.Swap.Deployment.Containers[i].Command))
+		normalizeStrings(&d.Swap.Deployment.Containers[i].Args)
+	}
+
+	if len(d.Environment) == 0 {
+		d.Environment = nil
+	}
+
+	if len(d.Mounts) == 0 {
+		d.Mounts = nil
+	}
+
+	if len(d.Scripts) == 0 {
+		d.Scripts = nil
+	}
+
+	return d
+}
+
+func normalizeStrings(s *[]string) {
+	if len(*s) == 0 {
+		*s = nil
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_RoundTripOK(t *testing.T) {
+	uid := int64(1000)
+	dev := &Dev{
+		Swap: Swap{
+			Deployment: Deployment{
+				Name:         "deployment",
+				Container:    "api",
+				Image:        "okteto/cnd",
+				Command:      []string{"uwsgi"},
+				Args:         []string{},
+				Capabilities: Capabilities{Add: []string{"SYS_PTRACE"}},
+			},
+		},
+		Mounts:  []Mount{{Source: ".", Target: "/app"}},
+		Sync:    Sync{IdleThreshold: 5 * time.Second, OwnerUID: &uid},
+		Scripts: map[string]string{"test": "make test"},
+		Editor:  "vi",
+	}
+
+	if err := dev.RoundTripOK(); err != nil {
+		t.Errorf("round trip failed: %s", err)
+	}
+
+	if err := NewDev().RoundTripOK(); err != nil {
+		t.Errorf("round trip of the default dev failed: %s", err)
+	}
+}
@@ -0,0 +1,366 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Schema is the JSON schema of the dev manifest. The 'deployment: name' shorthand is expanded before ValidateSchema checks it
+const Schema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "cnd manifest",
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["swap"],
+  "definitions": {
+    "strings": {"type": "array", "items": {"type": "string"}},
+    "stringMap": {"type": "object", "additionalProperties": {"type": "string"}},
+    "mount": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "source": {"type": "string"},
+        "target": {"type": "string"},
+        "enabled": {"type": "boolean"},
+        "readOnly": {"type": "boolean"},
+        "ignore": {"$ref": "#/definitions/strings"},
+        "remoteSource": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["repo"],
+          "properties": {
+            "repo": {"type": "string"},
+            "ref": {"type": "string"},
+            "path": {"type": "string"}
+          }
+        }
+      }
+    },
+    "deployment": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "container": {"type": "string"},
+        "image": {"type": "string"},
+        "imagePullSecrets": {"$ref": "#/definitions/strings"},
+        "command": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/strings"}]},
+        "args": {"$ref": "#/definitions/strings"},
+        "workdir": {"type": "string"},
+        "namespace": {"type": "string"},
+        "capabilities": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "add": {"$ref": "#/definitions/strings"},
+            "drop": {"$ref": "#/definitions/strings"}
+          }
+        },
+        "resources": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "requests": {"$ref": "#/definitions/stringMap"},
+            "limits": {"$ref": "#/definitions/stringMap"}
+          }
+        },
+        "containers": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["name"],
+            "properties": {
+              "name": {"type": "string"},
+              "image": {"type": "string"},
+              "command": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/strings"}]},
+              "args": {"$ref": "#/definitions/strings"},
+              "target": {"type": "string"}
+            }
+          }
+        }
+      }
+    }
+  },
+  "properties": {
+    "swap": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "deployment": {"$ref": "#/definitions/deployment"},
+        "selector": {"$ref": "#/definitions/stringMap"}
+      }
+    },
+    "mount": {"$ref": "#/definitions/mount"},
+    "mounts": {"type": "array", "items": {"$ref": "#/definitions/mount"}},
+    "sync": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "idleThreshold": {"type": "string"},
+        "ownerUID": {"type": "integer"},
+        "ownerGID": {"type": "integer"},
+        "mode": {"type": "string"}
+      }
+    },
+    "scripts": {
+      "type": "object",
+      "additionalProperties": {
+        "anyOf": [
+          {"type": "string"},
+          {"type": "object", "additionalProperties": false, "required": ["file"], "properties": {"file": {"type": "string"}}}
+        ]
+      }
+    },
+    "editor": {"type": "string"},
+    "apiVersion": {"type": "string"},
+    "syncImage": {"type": "string"},
+    "syncVolumeSize": {"type": "string"},
+    "forward": {"type": "array", "items": {"anyOf": [{"type": "string"}, {"type": "integer"}]}},
+    "environment": {
+      "type": "array",
+      "items": {
+        "anyOf": [
+          {"type": "string"},
+          {"type": "object", "additionalProperties": false, "required": ["name"], "properties": {"name": {"type": "string"}, "value": {"type": "string"}}}
+        ]
+      }
+    },
+    "ignore": {"$ref": "#/definitions/strings"},
+    "include": {"$ref": "#/definitions/strings"},
+    "lifecycle": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "postStart": {"$ref": "#/definitions/strings"},
+        "preStop": {"$ref": "#/definitions/strings"}
+      }
+    },
+    "down": {"$ref": "#/definitions/strings"},
+    "healthcheck": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "command": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/strings"}]},
+        "timeout": {"type": "string"},
+        "interval": {"type": "string"}
+      }
+    }
+  }
+}`
+
+// schema is the subset of JSON schema used by the manifest schema
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AnyOf                []*schema          `json:"anyOf"`
+	Definitions          map[string]*schema `json:"definitions"`
+}
+
+var manifestSchema = mustParseSchema(Schema)
+
+func mustParseSchema(s string) *schema {
+	var result schema
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		panic(fmt.Sprintf("invalid manifest schema: %s", err))
+	}
+
+	return &result
+}
+
+// ValidateSchema checks a yaml or json manifest against the manifest schema, e.g. to detect misspelled fields
+func ValidateSchema(b []byte) error {
+	var doc interface{}
+	var err error
+	if isJSONManifest(b) {
+		err = json.Unmarshal(b, &doc)
+	} else {
+		err = yaml.Unmarshal(b, &doc)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	dev := &Dev{}
+	if !isJSONManifest(b) {
+		dev.positions = getFieldPositions(b)
+	}
+
+	var errs []*FieldError
+	manifestSchema.validate(dev, "", expandDeploymentShorthand(normalizeYAML(doc)), &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// expandDeploymentShorthand replaces the 'deployment: name' shorthand with its mapping, like Deployment.UnmarshalYAML
+func expandDeploymentShorthand(doc interface{}) interface{} {
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc
+	}
+
+	swap, ok := m["swap"].(map[string]interface{})
+	if !ok {
+		return doc
+	}
+
+	if name, ok := swap["deployment"].(string); ok {
+		swap["deployment"] = map[string]interface{}{"name": name}
+	}
+
+	return doc
+}
+
+// normalizeYAML converts the yaml mappings to json objects
+func normalizeYAML(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			result[fmt.Sprintf("%v", k)] = normalizeYAML(item)
+		}
+		return result
+	case []interface{}:
+		for i := range value {
+			value[i] = normalizeYAML(value[i])
+		}
+		return value
+	}
+
+	return v
+}
+
+func (s *schema) resolve() *schema {
+	if s.Ref == "" {
+		return s
+	}
+
+	name := s.Ref[len("#/definitions/"):]
+	return manifestSchema.Definitions[name]
+}
+
+func (s *schema) validate(dev *Dev, field string, v interface{}, errs *[]*FieldError) {
+	s = s.resolve()
+	if len(s.AnyOf) > 0 {
+		for _, option := range s.AnyOf {
+			var optionErrs []*FieldError
+			option.validate(dev, field, v, &optionErrs)
+			if len(optionErrs) == 0 {
+				return
+			}
+		}
+
+		*errs = append(*errs, dev.fieldErrorf(field, "invalid value"))
+		return
+	}
+
+	if s.Type != "" && !hasSchemaType(s.Type, v) {
+		*errs = append(*errs, dev.fieldErrorf(field, "must be %s", schemaTypeNames[s.Type]))
+		return
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(dev, field, value, errs)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(dev, fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+	}
+}
+
+func (s *schema) validateObject(dev *Dev, field string, value map[string]interface{}, errs *[]*FieldError) {
+	for _, r := range s.Required {
+		if _, ok := value[r]; !ok {
+			*errs = append(*errs, dev.fieldErrorf(childField(field, r), "required"))
+		}
+	}
+
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var additional *schema
+	allowAdditional := true
+	if len(s.AdditionalProperties) > 0 {
+		if err := json.Unmarshal(s.AdditionalProperties, &allowAdditional); err != nil {
+			additional = &schema{}
+			json.Unmarshal(s.AdditionalProperties, additional)
+		}
+	}
+
+	for _, k := range keys {
+		if p, ok := s.Properties[k]; ok {
+			p.validate(dev, childField(field, k), value[k], errs)
+			continue
+		}
+
+		if additional != nil {
+			additional.validate(dev, childField(field, k), value[k], errs)
+			continue
+		}
+
+		if !allowAdditional {
+			*errs = append(*errs, dev.fieldErrorf(childField(field, k), "unknown field"))
+		}
+	}
+}
+
+func childField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return parent + "." + name
+}
+
+var schemaTypeNames = map[string]string{
+	"object":  "an object",
+	"array":   "an array",
+	"string":  "a string",
+	"integer": "an integer",
+	"boolean": "a boolean",
+}
+
+func hasSchemaType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		switch n := v.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ValidateSchema(t *testing.T) {
+	var tests = []struct {
+		name     string
+		manifest string
+		expected []string
+	}{
+		{
+			name: "valid",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    command: ["uwsgi"]
+mounts:
+  - source: .
+    target: /app
+    enabled: true
+sync:
+  idleThreshold: 10s
+  ownerUID: 1000
+scripts:
+  test: make test
+  build:
+    file: ./build.sh
+environment:
+  - DEBUG=true
+  - name: PORT
+    value: "8080"
+forward:
+  - 8080
+  - "5000:5000"`,
+		},
+		{
+			name:     "valid-json",
+			manifest: `{"swap": {"deployment": {"name": "deployment"}}, "sync": {"ownerUID": 1000}}`,
+		},
+		{
+			name: "shorthand-deployment",
+			manifest: `
+swap:
+  deployment: deployment`,
+		},
+		{
+			name: "invalid-command",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+    command: 1`,
+			expected: []string{"swap.deployment.command (line 5, column 5): invalid value"},
+		},
+		{
+			name: "misspelled",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+mont:
+  source: .`,
+			expected: []string{"mont (line 5, column 1): unknown field"},
+		},
+		{
+			name: "selector",
+			manifest: `
+swap:
+  selector:
+    app: api
+    release: prod`,
+		},
+		{
+			name: "required-and-types",
+			manifest: `
+swap:
+  deployment:
+    args: --http
+mounts:
+  - target: 1`,
+			expected: []string{
+				"mounts[0].target: must be a string",
+				"swap.deployment.args (line 4, column 5): must be an array",
+			},
+		},
+		{
+			name: "invalid-script",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+scripts:
+  build:
+    path: ./build.sh`,
+			expected: []string{"scripts.build (line 6, column 3): invalid value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema([]byte(tt.manifest))
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("valid manifest was rejected: %s", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("invalid manifest was accepted")
+			}
+
+			if err.Error() != strings.Join(tt.expected, "\n") {
+				t.Errorf("wrong errors:\n%s\nexpected:\n%s", err, strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// scriptPlaceholders are the manifest values a script can reference, e.g. 'cd {{.Target}} && make'
+var scriptPlaceholders = map[string]bool{
+	"Target":         true,
+	"Source":         true,
+	"DeploymentName": true,
+	"Container":      true,
+	"Image":          true,
+}
+
+// secretReference matches the ${SECRET:NAME} references of the scripts
+var secretReference = regexp.MustCompile(`\$\{` + secretPrefix + `([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// scriptContext are the manifest values rendered in the script placeholders
+type scriptContext struct {
+	Target         string
+	Source         string
+	DeploymentName string
+	Container      string
+	Image          string
+}
+
+// extractScriptFiles replaces the scripts of the manifest defined as a file reference, e.g. 'build: {file: ./build.sh}',
+// with an empty command, and returns them by script name. The manifest is returned unchanged when there are none
+func extractScriptFiles(b []byte, asJSON bool) ([]byte, map[string]string, error) {
+	if asJSON {
+		return extractJSONScriptFiles(b)
+	}
+
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		// the error is reported when decoding the dev
+		return b, nil, nil
+	}
+
+	files := map[string]string{}
+	for i := range doc {
+		if doc[i].Key != "scripts" {
+			continue
+		}
+
+		scripts, ok := doc[i].Value.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+
+		for j := range scripts {
+			ref, ok := scripts[j].Value.(yaml.MapSlice)
+			if !ok {
+				continue
+			}
+
+			name := fmt.Sprintf("%v", scripts[j].Key)
+			file, err := scriptFile(name, ref)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			files[name] = file
+			scripts[j].Value = ""
+		}
+	}
+
+	if len(files) == 0 {
+		return b, nil, nil
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, files, nil
+}
+
+func scriptFile(name string, ref yaml.MapSlice) (string, error) {
+	if len(ref) != 1 || ref[0].Key != "file" {
+		return "", fmt.Errorf("script '%s' must be a command or a 'file' reference", name)
+	}
+
+	file, ok := ref[0].Value.(string)
+	if !ok || file == "" {
+		return "", fmt.Errorf("script '%s' must reference a file", name)
+	}
+
+	return file, nil
+}
+
+func extractJSONScriptFiles(b []byte) ([]byte, map[string]string, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return b, nil, nil
+	}
+
+	var scripts map[string]json.RawMessage
+	if err := json.Unmarshal(doc["scripts"], &scripts); err != nil {
+		return b, nil, nil
+	}
+
+	files := map[string]string{}
+	for name, value := range scripts {
+		if !strings.HasPrefix(strings.TrimSpace(string(value)), "{") {
+			continue
+		}
+
+		var ref yaml.MapSlice
+		if err := yaml.Unmarshal(value, &ref); err != nil {
+			return nil, nil, err
+		}
+
+		file, err := scriptFile(name, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files[name] = file
+		scripts[name] = json.RawMessage(`""`)
+	}
+
+	if len(files) == 0 {
+		return b, nil, nil
+	}
+
+	raw, err := json.Marshal(scripts)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc["scripts"] = raw
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, files, nil
+}
+
+// resolveScriptFiles reads the scripts defined as a file reference, relative to the manifest folder
+func (dev *Dev) resolveScriptFiles(dir string) error {
+	for name, file := range dev.scriptFiles {
+		file, err := expandHome(file)
+		if err != nil {
+			return fmt.Errorf("script '%s': %s", name, err)
+		}
+
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("script '%s': error reading %s: %s", name, file, err)
+		}
+
+		dev.Scripts[name] = strings.TrimRight(string(b), "\n")
+	}
+
+	return nil
+}
+
+// ScriptNames returns the names of the scripts of the dev, sorted
+func (dev *Dev) ScriptNames() []string {
+	names := make([]string, 0, len(dev.Scripts))
+	for name := range dev.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// HasScript returns true if the dev defines the script
+func (dev *Dev) HasScript(name string) bool {
+	_, ok := dev.Scripts[name]
+	return ok
+}
+
+// RenderScript returns the command of a script with its placeholders replaced by the manifest values, e.g. 'cd {{.Target}} && make'
+func (dev *Dev) RenderScript(name string) (string, error) {
+	script, ok := dev.Scripts[name]
+	if !ok {
+		return "", fmt.Errorf("script '%s' is not defined", name)
+	}
+
+	t, err := template.New(name).Option("missingkey=error").Parse(script)
+	if err != nil {
+		return "", fmt.Errorf("script '%s' is not a valid template: %s", name, err)
+	}
+
+	mount := dev.MainMount()
+	ctx := scriptContext{
+		Target:         mount.Target,
+		Source:         mount.Source,
+		DeploymentName: dev.Swap.Deployment.Name,
+		Container:      dev.Swap.Deployment.Container,
+		Image:          dev.Swap.Deployment.Image,
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, ctx); err != nil {
+		return "", fmt.Errorf("error rendering script '%s': %s", name, err)
+	}
+
+	return resolveSecrets(name, b.String())
+}
+
+// resolveSecrets replaces the ${SECRET:NAME} references of a rendered script with the value of the NAME environment variable.
+// The resolved values are only returned to the caller, the scripts of the dev keep the references
+func resolveSecrets(name, script string) (string, error) {
+	var undefined []string
+	resolved := secretReference.ReplaceAllStringFunc(script, func(ref string) string {
+		secret := secretReference.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(secret)
+		if !ok {
+			undefined = append(undefined, secret)
+		}
+
+		return v
+	})
+
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("script '%s' references undefined secrets: %s", name, strings.Join(undefined, ", "))
+	}
+
+	return resolved, nil
+}
+
+// validateScripts checks that the placeholders of the scripts are valid templates and reference known values
+func (dev *Dev) validateScripts() []*FieldError {
+	var errs []*FieldError
+	for _, name := range dev.ScriptNames() {
+		field := "scripts." + name
+		t, err := template.New(name).Parse(dev.Scripts[name])
+		if err != nil {
+			errs = append(errs, dev.fieldErrorf(field, "Script '%s' is not a valid template: %s", name, err))
+			continue
+		}
+
+		for _, placeholder := range templateFields(t.Tree.Root) {
+			if !scriptPlaceholders[placeholder] {
+				errs = append(errs, dev.fieldErrorf(field, "Script '%s' references the unknown placeholder '{{.%s}}', escape the templates of other tools like {{`{{.Field}}`}}", name, placeholder))
+			}
+		}
+	}
+
+	return errs
+}
+
+// templateFields returns the names of the top level fields referenced by a template node
+func templateFields(node parse.Node) []string {
+	var fields []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, child := range n.Nodes {
+			fields = append(fields, templateFields(child)...)
+		}
+	case *parse.ActionNode:
+		fields = templateFields(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				fields = append(fields, templateFields(arg)...)
+			}
+		}
+	case *parse.FieldNode:
+		fields = []string{n.Ident[0]}
+	case *parse.ChainNode:
+		fields = templateFields(n.Node)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			fields = []string{n.Ident[1]}
+		}
+	case *parse.IfNode:
+		fields = branchFields(&n.BranchNode)
+	case *parse.RangeNode:
+		fields = branchFields(&n.BranchNode)
+	case *parse.WithNode:
+		fields = branchFields(&n.BranchNode)
+	case *parse.TemplateNode:
+		fields = templateFields(n.Pipe)
+	}
+
+	return fields
+}
+
+func branchFields(n *parse.BranchNode) []string {
+	fields := templateFields(n.Pipe)
+	fields = append(fields, templateFields(n.List)...)
+	return append(fields, templateFields(n.ElseList)...)
+}
@@ -0,0 +1,238 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ReadDevScriptFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-scripts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "hack"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "hack", "build.sh"), []byte("make build\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name     string
+		file     string
+		manifest string
+	}{
+		{
+			name: "yaml",
+			file: "cnd.yml",
+			manifest: `
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - target: /app
+scripts:
+  build:
+    file: ./hack/build.sh
+  test: make test`,
+		},
+		{
+			name:     "json",
+			file:     "cnd.json",
+			manifest: `{"swap": {"deployment": {"name": "deployment"}}, "mounts": [{"target": "/app"}], "scripts": {"build": {"file": "./hack/build.sh"}, "test": "make test"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devPath := filepath.Join(dir, tt.file)
+			if err := ioutil.WriteFile(devPath, []byte(tt.manifest), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			d, err := ReadDev(devPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d.Scripts["build"] != "make build" || d.Scripts["test"] != "make test" {
+				t.Errorf("scripts were not resolved: %+v", d.Scripts)
+			}
+		})
+	}
+
+	devPath := filepath.Join(dir, "missing.yml")
+	if err := ioutil.WriteFile(devPath, []byte(`
+swap:
+  deployment:
+    name: deployment
+mounts:
+  - target: /app
+scripts:
+  build:
+    file: ./hack/missing.sh`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadDev(devPath)
+	if err == nil || !strings.Contains(err.Error(), "script 'build'") || !strings.Contains(err.Error(), "missing.sh") {
+		t.Errorf("wrong error for a missing file: %v", err)
+	}
+}
+
+func Test_extractScriptFilesInvalid(t *testing.T) {
+	_, _, err := extractScriptFiles([]byte(`
+scripts:
+  build:
+    command: make`), false)
+	if err == nil {
+		t.Errorf("script without a file was accepted")
+	}
+}
+
+func Test_validateScripts(t *testing.T) {
+	var tests = []struct {
+		name     string
+		script   string
+		expected string
+	}{
+		{name: "plain", script: "make test"},
+		{name: "placeholders", script: "cd {{.Target}} && make {{.DeploymentName}}-{{.Container}} SRC={{ .Source }}"},
+		{name: "conditional", script: "{{if .Container}}echo {{.Container}}{{else}}echo {{$.Target}}{{end}}"},
+		{name: "unknown", script: "cd {{.Taget}}", expected: "scripts.unknown: Script 'unknown' references the unknown placeholder '{{.Taget}}'"},
+		{name: "unknown-in-branch", script: "{{with .Source}}{{.}}{{end}}{{if .Tag}}x{{end}}", expected: "references the unknown placeholder '{{.Tag}}'"},
+		{name: "invalid", script: "cd {{.Target", expected: "Script 'invalid' is not a valid template"},
+		{name: "escaped", script: "docker inspect -f '{{`{{.State.Running}}`}}' {{.Container}}"},
+		{name: "escaped-delimiters", script: `helm get values {{"{{"}}.Release{{"}}"}}`},
+		{name: "unescaped", script: "docker inspect -f '{{.State.Running}}' api", expected: "unknown placeholder '{{.State}}', escape the templates of other tools like {{`{{.Field}}`}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &Dev{Scripts: map[string]string{tt.name: tt.script}}
+			errs := dev.validateScripts()
+			if tt.expected == "" {
+				if len(errs) > 0 {
+					t.Errorf("valid script was rejected: %s", errs[0])
+				}
+				return
+			}
+
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("wrong errors, expected '%s': %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+func Test_RenderScript(t *testing.T) {
+	dev := &Dev{
+		Swap:   Swap{Deployment: Deployment{Name: "api", Container: "app", Image: "okteto/api:1.0"}},
+		Mounts: []Mount{{Source: "/home/cnd/api", Target: "/app"}},
+		Scripts: map[string]string{
+			"build":   "cd {{.Target}} && make",
+			"push":    "docker push {{.Image}} # {{.DeploymentName}}/{{.Container}} from {{.Source}}",
+			"plain":   "make test",
+			"broken":  "cd {{.Target",
+			"inspect": "docker inspect -f '{{`{{.State.Running}}`}}' {{.Container}}",
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		expected string
+	}{
+		{name: "build", expected: "cd /app && make"},
+		{name: "push", expected: "docker push okteto/api:1.0 # api/app from /home/cnd/api"},
+		{name: "plain", expected: "make test"},
+		{name: "inspect", expected: "docker inspect -f '{{.State.Running}}' app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := dev.RenderScript(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rendered != tt.expected {
+				t.Errorf("%s != %s", rendered, tt.expected)
+			}
+		})
+	}
+
+	if _, err := dev.RenderScript("missing"); err == nil || !strings.Contains(err.Error(), "is not defined") {
+		t.Errorf("missing script was rendered: %v", err)
+	}
+
+	if _, err := dev.RenderScript("broken"); err == nil {
+		t.Error("invalid script was rendered")
+	}
+}
+
+func Test_RenderScriptSecrets(t *testing.T) {
+	os.Setenv("CND_TEST_TOKEN", "s3cr3t")
+	defer os.Unsetenv("CND_TEST_TOKEN")
+
+	dev, err := LoadDev([]byte(`
+swap:
+  deployment:
+    name: api
+scripts:
+  deploy: "deploy --token ${SECRET:CND_TEST_TOKEN} {{.DeploymentName}}"
+  missing: "deploy --token ${SECRET:CND_TEST_UNDEFINED}"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.Scripts["deploy"] != "deploy --token ${SECRET:CND_TEST_TOKEN} {{.DeploymentName}}" {
+		t.Errorf("the secret was resolved when reading the manifest: %s", dev.Scripts["deploy"])
+	}
+
+	rendered, err := dev.RenderScript("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rendered != "deploy --token s3cr3t api" {
+		t.Errorf("the secret wasn't resolved: %s", rendered)
+	}
+
+	if strings.Contains(dev.Scripts["deploy"], "s3cr3t") {
+		t.Errorf("the secret was stored in the dev: %s", dev.Scripts["deploy"])
+	}
+
+	if _, err := dev.RenderScript("missing"); err == nil || !strings.Contains(err.Error(), "CND_TEST_UNDEFINED") {
+		t.Errorf("undefined secret was rendered: %v", err)
+	}
+}
+
+func Test_ScriptNames(t *testing.T) {
+	dev := &Dev{Scripts: map[string]string{"test": "make test", "build": "make", "lint": "make lint", "deploy": "make deploy"}}
+	expected := []string{"build", "deploy", "lint", "test"}
+	for i := 0; i < 5; i++ {
+		if names := dev.ScriptNames(); !reflect.DeepEqual(names, expected) {
+			t.Fatalf("%v != %v", names, expected)
+		}
+	}
+
+	if !dev.HasScript("lint") || dev.HasScript("missing") {
+		t.Errorf("wrong scripts: %v", dev.Scripts)
+	}
+
+	empty := &Dev{}
+	if names := empty.ScriptNames(); names == nil || len(names) != 0 {
+		t.Errorf("wrong names without scripts: %#v", names)
+	}
+
+	if empty.HasScript("test") {
+		t.Errorf("a script was found without scripts")
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrSourceTooLarge indicates the mount sources have more files than the estimation walks
+	ErrSourceTooLarge = fmt.Errorf("mount source is too large to estimate")
+
+	// maxEstimatedFiles caps the files walked by EstimateSourceSize, so huge trees like a home folder don't hang it
+	maxEstimatedFiles int64 = 1000000
+)
+
+// EstimateSourceSize returns the number of files and bytes under the enabled mount sources, e.g. to warn before a massive sync.
+// It returns ErrSourceTooLarge, with the counts so far, when the sources have more files than the walk cap
+func (dev *Dev) EstimateSourceSize() (int64, int64, error) {
+	var files, bytes int64
+	for _, m := range dev.EnabledMounts() {
+		if m.IsRemote() {
+			continue
+		}
+
+		err := filepath.Walk(m.Source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			if files >= maxEstimatedFiles {
+				return ErrSourceTooLarge
+			}
+
+			files++
+			bytes += info.Size()
+			return nil
+		})
+
+		if err != nil {
+			return files, bytes, err
+		}
+	}
+
+	return files, bytes, nil
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_EstimateSourceSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{"main.go": "package main", "src/app.go": "package src", "src/pkg/lib.go": "package pkg"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dev := &Dev{Mounts: []Mount{{Source: dir, Target: "/src"}}}
+	files, bytes, err := dev.EstimateSourceSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files != 3 || bytes != 34 {
+		t.Errorf("wrong estimation: %d files, %d bytes", files, bytes)
+	}
+
+	previous := maxEstimatedFiles
+	maxEstimatedFiles = 2
+	defer func() { maxEstimatedFiles = previous }()
+
+	files, _, err = dev.EstimateSourceSize()
+	if err != ErrSourceTooLarge {
+		t.Errorf("walk cap was not hit: %v", err)
+	}
+
+	if files != 2 {
+		t.Errorf("wrong files before the walk cap: %d", files)
+	}
+}
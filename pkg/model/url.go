@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// manifestURLTimeout is the timeout to fetch a manifest from a url
+var manifestURLTimeout = 30 * time.Second
+
+// ReadDevURL returns a Dev object from a yaml or json manifest served over http or https.
+// Like in ReadDevFrom, relative mount sources and script files are resolved against the current working directory
+func ReadDevURL(manifestURL string) (*Dev, error) {
+	u, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid manifest url: %s", manifestURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("'%s' is not a valid manifest url: the scheme must be http or https", manifestURL)
+	}
+
+	client := &http.Client{Timeout: manifestURLTimeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("error getting the manifest %s: %s", manifestURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("error getting the manifest %s: %s", manifestURL, resp.Status)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, &ManifestError{Kind: ErrManifestNotFound, Err: err}
+		}
+
+		return nil, err
+	}
+
+	return ReadDevFrom(resp.Body)
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ReadDevURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cnd.yml":
+			fmt.Fprint(w, "swap:\n  deployment:\n    name: api\nmounts:\n  - source: .\n    target: /app")
+		case "/invalid.yml":
+			fmt.Fprint(w, "swap:\n  deployment:\n    name: \"\"")
+		case "/slow.yml":
+			time.Sleep(100 * time.Millisecond)
+		case "/error.yml":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	d, err := ReadDevURL(server.URL + "/cnd.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Swap.Deployment.Name != "api" || !strings.HasPrefix(d.Mounts[0].Source, "/") {
+		t.Errorf("wrong dev: %+v", d)
+	}
+
+	if _, err := ReadDevURL(server.URL + "/invalid.yml"); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("invalid manifest was accepted: %v", err)
+	}
+
+	if _, err := ReadDevURL(server.URL + "/missing.yml"); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("missing manifest isn't ErrManifestNotFound: %v", err)
+	}
+
+	if _, err := ReadDevURL(server.URL + "/error.yml"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("http error wasn't reported: %v", err)
+	}
+
+	if _, err := ReadDevURL("file:///etc/cnd.yml"); err == nil || !strings.Contains(err.Error(), "http or https") {
+		t.Errorf("file url was accepted: %v", err)
+	}
+
+	defer func(timeout time.Duration) {
+		manifestURLTimeout = timeout
+	}(manifestURLTimeout)
+	manifestURLTimeout = 10 * time.Millisecond
+	if _, err := ReadDevURL(server.URL + "/slow.yml"); err == nil {
+		t.Errorf("slow server didn't time out")
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"fmt"
+)
+
+// VerifyDeployment checks that the deployment of the dev exists, e.g. before activating it. The namespace takes precedence
+// over swap.deployment.namespace, like the --namespace flag. exists is wired by the caller to its kubernetes client
+func (dev *Dev) VerifyDeployment(exists func(namespace, name string) (bool, error), namespace string) error {
+	if namespace == "" {
+		namespace = dev.Swap.Deployment.Namespace
+	}
+
+	ok, err := exists(namespace, dev.Swap.Deployment.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check the deployment '%s': %s", dev.Swap.Deployment.Name, err)
+	}
+
+	if ok {
+		return nil
+	}
+
+	if namespace == "" {
+		return fmt.Errorf("the deployment '%s' doesn't exist in the current namespace", dev.Swap.Deployment.Name)
+	}
+
+	return fmt.Errorf("the deployment '%s' doesn't exist in the namespace '%s'", dev.Swap.Deployment.Name, namespace)
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_VerifyDeployment(t *testing.T) {
+	existing := map[string]bool{"project/api": true}
+	exists := func(namespace, name string) (bool, error) {
+		if namespace == "broken" {
+			return false, fmt.Errorf("connection refused")
+		}
+
+		return existing[namespace+"/"+name], nil
+	}
+
+	var tests = []struct {
+		name      string
+		dev       *Dev
+		namespace string
+		expected  string
+	}{
+		{
+			name:      "exists",
+			dev:       &Dev{Swap: Swap{Deployment: Deployment{Name: "api"}}},
+			namespace: "project",
+		},
+		{
+			name: "manifest-namespace",
+			dev:  &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Namespace: "project"}}},
+		},
+		{
+			name:      "missing",
+			dev:       &Dev{Swap: Swap{Deployment: Deployment{Name: "web"}}},
+			namespace: "project",
+			expected:  "the deployment 'web' doesn't exist in the namespace 'project'",
+		},
+		{
+			name:     "missing-current-namespace",
+			dev:      &Dev{Swap: Swap{Deployment: Deployment{Name: "api"}}},
+			expected: "the deployment 'api' doesn't exist in the current namespace",
+		},
+		{
+			name:      "flag-precedence",
+			dev:       &Dev{Swap: Swap{Deployment: Deployment{Name: "api", Namespace: "project"}}},
+			namespace: "other",
+			expected:  "the deployment 'api' doesn't exist in the namespace 'other'",
+		},
+		{
+			name:      "error",
+			dev:       &Dev{Swap: Swap{Deployment: Deployment{Name: "api"}}},
+			namespace: "broken",
+			expected:  "failed to check the deployment 'api': connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dev.VerifyDeployment(exists, tt.namespace)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("existing deployment was rejected: %s", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("wrong error, expected '%s': %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Backend reads and writes the storage, e.g. to keep the state of cnd somewhere else than the storage file.
+// The storage returned by Load is modified by the caller, so it must not be shared with the backend
+type Backend interface {
+	Load() (*Storage, error)
+	Save(*Storage) error
+}
+
+// backend is where the package functions read and write the storage
+var backend Backend = fileBackend{}
+
+// SetBackend replaces the backend of the storage. A nil backend restores the storage file
+func SetBackend(b Backend) {
+	storageMutex.Lock()
+	defer storageMutex.Unlock()
+	if b == nil {
+		b = fileBackend{}
+	}
+
+	backend = b
+}
+
+// WithMemoryBackend runs fn with an empty in-memory storage, restoring the previous backend afterwards, even if fn panics.
+// It's meant for the tests of the packages calling the storage functions, which must not run in parallel
+func WithMemoryBackend(fn func()) {
+	storageMutex.RLock()
+	previous := backend
+	storageMutex.RUnlock()
+	defer SetBackend(previous)
+
+	SetBackend(NewMemoryBackend())
+	fn()
+}
+
+// fileBackend is the default backend, the storage file at StoragePath
+type fileBackend struct{}
+
+func (fileBackend) Load() (*Storage, error) {
+	return loadFile()
+}
+
+func (fileBackend) Save(s *Storage) error {
+	return s.saveFile()
+}
+
+// memoryBackend keeps the marshalled storage in memory
+type memoryBackend struct {
+	data []byte
+}
+
+// NewMemoryBackend returns a backend that keeps the storage in memory, e.g. for tests or ephemeral environments
+func NewMemoryBackend() Backend {
+	return &memoryBackend{}
+}
+
+func (m *memoryBackend) Load() (*Storage, error) {
+	s := &Storage{Version: version, Services: map[string]Service{}}
+	if err := yaml.Unmarshal(m.data, s); err != nil {
+		return nil, fmt.Errorf("error unmarshalling the storage: %s", err.Error())
+	}
+
+	if s.Services == nil {
+		s.Services = map[string]Service{}
+	}
+
+	return s, nil
+}
+
+func (m *memoryBackend) Save(s *Storage) error {
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error marshalling storage: %s", err.Error())
+	}
+
+	m.data = b
+	return nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	SetBackend(NewMemoryBackend())
+	defer SetBackend(nil)
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}, Mounts: []model.Mount{{Source: "/api", Target: "/app"}}}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.Folder != "/api" || svc.Syncthing != "localhost" {
+		t.Errorf("wrong service: %+v", svc)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".state")); !os.IsNotExist(err) {
+		t.Errorf("the storage file was written: %v", err)
+	}
+
+	if outcome, err := PreviewInsert("project", &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "web"}}, Mounts: []model.Mount{{Source: "/web"}}}, ""); err != nil || outcome != InsertCreated {
+		t.Errorf("wrong outcome: %s, %v", outcome, err)
+	}
+
+	if len(All()) != 1 {
+		t.Errorf("the preview was saved in the backend: %+v", All())
+	}
+
+	SetBackend(nil)
+	if len(All()) != 0 {
+		t.Errorf("the storage file wasn't restored: %+v", All())
+	}
+}
+
+func TestWithMemoryBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	previous := NewMemoryBackend()
+	SetBackend(previous)
+	defer SetBackend(nil)
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}, Mounts: []model.Mount{{Source: "/api", Target: "/app"}}}
+	WithMemoryBackend(func() {
+		if backend == previous {
+			t.Fatal("the backend wasn't replaced")
+		}
+
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+
+		svc, err := Get("project", dev)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if svc.Folder != "/api" || svc.Syncthing != "localhost" {
+			t.Errorf("wrong service: %+v", svc)
+		}
+
+		if err := Delete("project", dev); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Get("project", dev); err == nil {
+			t.Errorf("the service wasn't deleted")
+		}
+
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if backend != previous {
+		t.Errorf("the previous backend wasn't restored")
+	}
+
+	if len(All()) != 0 {
+		t.Errorf("the services of the memory backend were kept: %+v", All())
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("the panic wasn't propagated")
+			}
+		}()
+
+		WithMemoryBackend(func() { panic("failed") })
+	}()
+
+	if backend != previous {
+		t.Errorf("the previous backend wasn't restored after a panic")
+	}
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"time"
+)
+
+// Clock returns the current time of the timestamps set by the storage, e.g. StartedAt
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default clock, the system time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+var clock Clock = realClock{}
+
+// SetClock replaces the clock of the storage timestamps, e.g. to make them deterministic in tests. A nil clock restores the system time
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+
+	clock = c
+}
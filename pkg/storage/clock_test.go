@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestSetClock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	now := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(fixedClock(now))
+	defer SetClock(nil)
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}, Mounts: []model.Mount{{Source: "/api"}}}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !svc.StartedAt.Equal(now) {
+		t.Errorf("%s != %s", svc.StartedAt, now)
+	}
+
+	SetClock(nil)
+	if _, ok := clock.(realClock); !ok {
+		t.Errorf("the real clock wasn't restored: %T", clock)
+	}
+}
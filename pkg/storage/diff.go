@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"reflect"
+	"sort"
+)
+
+// StorageDiff are the service entries that changed between two storage states
+type StorageDiff struct {
+	Added   []string
+	Removed []string
+	Changed []ServiceChange
+}
+
+// ServiceChange is a service entry with different values in two storage states
+type ServiceChange struct {
+	Name   string
+	Before Service
+	After  Service
+}
+
+// IsEmpty returns true if both storage states have the same service entries
+func (d StorageDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Load returns a snapshot of the storage, e.g. to compare it with Diff after an operation
+func Load() (*Storage, error) {
+	return loadShared()
+}
+
+// Diff returns the service entries added, removed and changed from a to b, sorted by name. A nil storage has no entries
+func Diff(a, b *Storage) StorageDiff {
+	before := map[string]Service{}
+	if a != nil {
+		before = a.Services
+	}
+
+	after := map[string]Service{}
+	if b != nil {
+		after = b.Services
+	}
+
+	var diff StorageDiff
+	for name, svc := range after {
+		previous, ok := before[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+
+		if !reflect.DeepEqual(previous, svc) {
+			diff.Changed = append(diff.Changed, ServiceChange{Name: name, Before: previous, After: svc})
+		}
+	}
+
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Name < diff.Changed[j].Name
+	})
+
+	return diff
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev1 := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	dev2 := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service2"}}, Mounts: []model.Mount{{Source: "/folder2"}}}
+	dev3 := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service3"}}, Mounts: []model.Mount{{Source: "/folder3"}}}
+	for _, dev := range []*model.Dev{dev1, dev2} {
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := Diff(before, before); !diff.IsEmpty() {
+		t.Errorf("the same state has differences: %+v", diff)
+	}
+
+	if err := Stop("project", dev1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Delete("project", dev2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Insert("project", dev3, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := Diff(before, after)
+	if !reflect.DeepEqual(diff.Added, []string{"project/service3/"}) {
+		t.Errorf("wrong added services: %+v", diff.Added)
+	}
+
+	if !reflect.DeepEqual(diff.Removed, []string{"project/service2/"}) {
+		t.Errorf("wrong removed services: %+v", diff.Removed)
+	}
+
+	if len(diff.Changed) != 1 || diff.Changed[0].Name != "project/service1/" || diff.Changed[0].Before.Syncthing != "localhost" || diff.Changed[0].After.Syncthing != "" {
+		t.Errorf("wrong changed services: %+v", diff.Changed)
+	}
+
+	if diff := Diff(nil, after); len(diff.Added) != 2 || len(diff.Removed) != 0 {
+		t.Errorf("wrong diff from an empty state: %+v", diff)
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/okteto/cnd/pkg/model"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ExportManifests returns a minimal dev for each service entry, keyed by service name, e.g. to share the active sessions as manifests.
+// Only the deployment, container and namespace, parsed from the name, and the main mount are recovered.
+// The image, command, scripts and the rest of the manifest aren't stored, so they are left empty
+func ExportManifests() (map[string]*model.Dev, error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, err
+	}
+
+	devs := make(map[string]*model.Dev, len(s.Services))
+	for name, svc := range s.Services {
+		_, namespace, deployment, container, err := parseFullName(name)
+		if err != nil {
+			log.Debugf("ignoring service entry: %s", err)
+			continue
+		}
+
+		devs[name] = &model.Dev{
+			Swap: model.Swap{
+				Deployment: model.Deployment{
+					Name:      deployment,
+					Namespace: namespace,
+					Container: container,
+				},
+			},
+			Mounts: []model.Mount{{Source: svc.Folder, Target: svc.Target}},
+		}
+	}
+
+	return devs, nil
+}
+
+// Export returns the whole storage, with its version and checksum, e.g. to carry the cnd sessions to another machine with Import
+func Export() ([]byte, error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := s.checksum()
+	if err != nil {
+		return nil, err
+	}
+	s.Checksum = checksum
+
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling storage: %s", err.Error())
+	}
+
+	return b, nil
+}
+
+// Import adds the service entries of a storage returned by Export, replacing the entries with the same name.
+// If overwrite is set, the current entries are removed first. Nothing is imported if any entry is invalid,
+// e.g. if its folder is a relative path, which wouldn't be valid on another machine
+func Import(b []byte, overwrite bool) error {
+	var imported Storage
+	if err := yaml.Unmarshal(b, &imported); err != nil {
+		return fmt.Errorf("error unmarshalling the imported storage: %s", err.Error())
+	}
+
+	if imported.Checksum != "" {
+		checksum, err := imported.checksum()
+		if err != nil {
+			return err
+		}
+
+		if checksum != imported.Checksum {
+			return ErrStorageCorrupt
+		}
+	}
+
+	if err := migrate(&imported); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(imported.Services))
+	for name := range imported.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := imported.Services[name].validate(); err != nil {
+			return fmt.Errorf("the imported service entry %s is not valid: %s", name, err)
+		}
+	}
+
+	l, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	if overwrite {
+		s.Services = map[string]Service{}
+	}
+
+	for name, svc := range imported.Services {
+		s.Services[name] = svc
+	}
+
+	return s.save()
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestExportManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "api", Container: "app", Image: "okteto/api:1.0"}},
+		Mounts: []model.Mount{{Source: "/home/user/api", Target: "/app"}},
+	}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Services["invalid"] = Service{Folder: "/invalid"}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	devs, err := ExportManifests()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devs) != 1 {
+		t.Fatalf("wrong devs: %+v", devs)
+	}
+
+	d := devs["project/api/app"]
+	if d == nil {
+		t.Fatalf("the service wasn't exported: %+v", devs)
+	}
+
+	if d.Swap.Deployment.Name != "api" || d.Swap.Deployment.Container != "app" || d.Swap.Deployment.Namespace != "project" {
+		t.Errorf("wrong deployment: %+v", d.Swap.Deployment)
+	}
+
+	if len(d.Mounts) != 1 || d.Mounts[0].Source != "/home/user/api" || d.Mounts[0].Target != "/app" {
+		t.Errorf("wrong mounts: %+v", d.Mounts)
+	}
+
+	if d.Swap.Deployment.Image != "" {
+		t.Errorf("the image was recovered: %s", d.Swap.Deployment.Image)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	for _, name := range []string{"api", "web"} {
+		dev := &model.Dev{
+			Swap:   model.Swap{Deployment: model.Deployment{Name: name, Container: "app"}},
+			Mounts: []model.Mount{{Source: "/home/user/" + name, Target: "/app"}},
+		}
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetStoragePath(filepath.Join(dir, ".other"))
+	dev := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "db", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/db", Target: "/app"}},
+	}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Import(b, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if services := All(); len(services) != 3 || services["project/api/app"].Folder != "/home/user/api" {
+		t.Errorf("the services weren't merged: %+v", services)
+	}
+
+	if err := Import(b, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if services := All(); len(services) != 2 || services["project/web/app"].Folder != "/home/user/web" {
+		t.Errorf("the services weren't overwritten: %+v", services)
+	}
+
+	relative := []byte("version: \"1.0\"\nservices:\n  project/api/app:\n    folder: api\n")
+	if err := Import(relative, true); err == nil || !strings.Contains(err.Error(), "not an absolute path") {
+		t.Errorf("relative folder was imported: %v", err)
+	}
+
+	if services := All(); len(services) != 2 {
+		t.Errorf("the services changed after a failed import: %+v", services)
+	}
+
+	b[len(b)-2] = 'x'
+	if err := Import(b, false); err == nil {
+		t.Errorf("modified storage was imported")
+	}
+}
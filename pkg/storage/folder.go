@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// canonicalFolder returns the folder with the casing of its entries on disk when it's on a case-insensitive filesystem,
+// like the default one of macOS, so /Users/Me/proj and /Users/me/proj are the same service folder.
+// Folders on case-sensitive filesystems, e.g. on Linux, and missing folders are returned unchanged
+func canonicalFolder(folder string) string {
+	if !isCaseInsensitive(folder) {
+		return folder
+	}
+
+	return canonicalCase(folder)
+}
+
+// isCaseInsensitive returns true if p is also found with its letters in the opposite case
+func isCaseInsensitive(p string) bool {
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+
+		return unicode.ToUpper(r)
+	}, p)
+	if swapped == p {
+		return false
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return false
+	}
+
+	other, err := os.Stat(swapped)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(info, other)
+}
+
+// canonicalCase replaces each element of p with the entry of its parent folder with the same name ignoring the case.
+// The elements without such an entry are kept
+func canonicalCase(p string) string {
+	parent := filepath.Dir(p)
+	if parent == p {
+		return p
+	}
+
+	parent = canonicalCase(parent)
+	name := filepath.Base(p)
+	f, err := os.Open(parent)
+	if err != nil {
+		return filepath.Join(parent, name)
+	}
+	defer f.Close()
+
+	entries, err := f.Readdirnames(-1)
+	if err != nil {
+		return filepath.Join(parent, name)
+	}
+
+	match := name
+	for _, entry := range entries {
+		if entry == name {
+			return filepath.Join(parent, entry)
+		}
+
+		if match == name && strings.EqualFold(entry, name) {
+			match = entry
+		}
+	}
+
+	return filepath.Join(parent, match)
+}
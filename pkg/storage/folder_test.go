@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folder := filepath.Join(dir, "Users", "Me", "proj")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	typed := filepath.Join(dir, "Users", "me", "proj")
+	if c := canonicalCase(typed); c != folder {
+		t.Errorf("%s != %s", c, folder)
+	}
+
+	if c := canonicalCase(filepath.Join(dir, "missing", "Proj")); c != filepath.Join(dir, "missing", "Proj") {
+		t.Errorf("the missing folder was changed: %s", c)
+	}
+
+	// the default filesystem of macOS is case-insensitive, so both paths are the same folder.
+	// Linux filesystems are case-sensitive, and the path is kept as typed
+	expected := typed
+	if isCaseInsensitive(folder) {
+		expected = folder
+	}
+
+	if c := canonicalFolder(typed); c != expected {
+		t.Errorf("%s != %s", c, expected)
+	}
+
+	if c := canonicalFolder(folder); c != folder {
+		t.Errorf("%s != %s", c, folder)
+	}
+}
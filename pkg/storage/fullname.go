@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// FullName is the key of a service entry, [context/]namespace/deployment/container with each segment escaped
+type FullName struct {
+	Context    string
+	Namespace  string
+	Deployment string
+	Container  string
+}
+
+// ParseFullName parses the key of a service entry. The context is empty for the keys without one
+func ParseFullName(key string) (FullName, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) == 3 {
+		parts = append([]string{""}, parts...)
+	}
+
+	if len(parts) != 4 {
+		return FullName{}, fmt.Errorf("'%s' is not a [context/]namespace/deployment/container name", key)
+	}
+
+	for i := range parts {
+		segment, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return FullName{}, fmt.Errorf("'%s' is not a valid service name: %s", key, err)
+		}
+		parts[i] = segment
+	}
+
+	if parts[2] == "" {
+		return FullName{}, fmt.Errorf("'%s' is not a valid service name: the deployment is empty", key)
+	}
+
+	return FullName{Context: parts[0], Namespace: parts[1], Deployment: parts[2], Container: parts[3]}, nil
+}
+
+// String returns the key of the service entry, which ParseFullName always parses back
+func (f FullName) String() string {
+	name := fmt.Sprintf("%s/%s/%s", url.PathEscape(f.Namespace), url.PathEscape(f.Deployment), url.PathEscape(f.Container))
+	if f.Context == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s/%s", url.PathEscape(f.Context), name)
+}
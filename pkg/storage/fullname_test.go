@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestParseFullName(t *testing.T) {
+	var tests = []struct {
+		key      string
+		expected FullName
+		err      bool
+	}{
+		{key: "project1/api/app", expected: FullName{Namespace: "project1", Deployment: "api", Container: "app"}},
+		{key: "project1/api/", expected: FullName{Namespace: "project1", Deployment: "api"}},
+		{key: "kind%2Flocal/team%2Fdev/api/app", expected: FullName{Context: "kind/local", Namespace: "team/dev", Deployment: "api", Container: "app"}},
+		{key: "", err: true},
+		{key: "api", err: true},
+		{key: "project1/api", err: true},
+		{key: "kind/project1/api/app/worker", err: true},
+		{key: "project1//app", err: true},
+		{key: "project1/api%zz/app", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			f, err := ParseFullName(tt.key)
+			if tt.err {
+				if err == nil {
+					t.Errorf("the malformed key was parsed: %+v", f)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f != tt.expected {
+				t.Errorf("%+v != %+v", f, tt.expected)
+			}
+
+			if f.String() != tt.key {
+				t.Errorf("%s != %s", f.String(), tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// serviceJSON is the json representation of a service, with the field names of the storage file
+type serviceJSON struct {
+	Folder          string            `json:"folder,omitempty"`
+	Target          string            `json:"target,omitempty"`
+	Manifest        string            `json:"manifest,omitempty"`
+	Syncthing       string            `json:"syncthing,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	Context         string            `json:"context,omitempty"`
+	StartedAt       *time.Time        `json:"started_at,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	Config          map[string]string `json:"config,omitempty"`
+	OriginalCommand []string          `json:"original_command,omitempty"`
+	OriginalArgs    []string          `json:"original_args,omitempty"`
+}
+
+// ServicesJSON returns the active cnd services as a json object keyed by service name, e.g. for scripts
+func ServicesJSON() ([]byte, error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]serviceJSON, len(s.Services))
+	for name, svc := range s.Services {
+		result := serviceJSON{
+			Folder:          svc.Folder,
+			Target:          svc.Target,
+			Manifest:        svc.Manifest,
+			Syncthing:       svc.Syncthing,
+			Status:          svc.Status,
+			Context:         svc.Context,
+			Metadata:        svc.Metadata,
+			Config:          svc.Config,
+			OriginalCommand: svc.OriginalCommand,
+			OriginalArgs:    svc.OriginalArgs,
+		}
+
+		if !svc.StartedAt.IsZero() {
+			startedAt := svc.StartedAt.UTC()
+			result.StartedAt = &startedAt
+		}
+
+		services[name] = result
+	}
+
+	// encoding/json sorts the map keys, so the output is stable
+	return json.Marshal(services)
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestServicesJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	b, err := ServicesJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "{}" {
+		t.Errorf("wrong json for an empty storage: %s", b)
+	}
+
+	for _, name := range []string{"web", "api"} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name}}, Mounts: []model.Mount{{Source: "/" + name}}}
+		if err := Insert("project", dev, "localhost:"+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := s.Services["project/api/"]
+	api.StartedAt = time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	api.Status = StatusSynced
+	api.Config = nil
+	s.Services["project/api/"] = api
+	web := s.Services["project/web/"]
+	web.StartedAt = time.Time{}
+	web.Config = nil
+	s.Services["project/web/"] = web
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = ServicesJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"project/api/":{"folder":"/api","syncthing":"localhost:api","status":"synced","started_at":"2019-01-02T03:04:05Z"},"project/web/":{"folder":"/web","syncthing":"localhost:web"}}`
+	if string(b) != expected {
+		t.Errorf("%s != %s", b, expected)
+	}
+}
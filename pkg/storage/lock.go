@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// lockTimeout is how long a command waits for another one to release the storage file
+	lockTimeout = 5 * time.Second
+
+	lockRetryInterval = 50 * time.Millisecond
+
+	// storageMutex serializes the goroutines of this process, the file lock only works across processes.
+	// It's taken before the file lock, so both are always acquired in the same order
+	storageMutex sync.RWMutex
+)
+
+// acquireLock takes the advisory lock of the storage file, serializing the read-modify-write cycles across processes.
+// Other backends are only serialized within the process, and no file is returned
+func acquireLock() (*os.File, error) {
+	storageMutex.Lock()
+	if _, ok := backend.(fileBackend); !ok {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(stPath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		storageMutex.Unlock()
+		return nil, fmt.Errorf("error opening the storage lock: %s", err.Error())
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			storageMutex.Unlock()
+			return nil, fmt.Errorf("error acquiring the storage lock: %s", err.Error())
+		}
+
+		if locked {
+			return f, nil
+		}
+
+		if time.Now().After(deadline) {
+			f.Close()
+			storageMutex.Unlock()
+			return nil, fmt.Errorf("could not acquire storage lock within %s", lockTimeout)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// releaseLock releases a lock returned by acquireLock
+func releaseLock(f *os.File) {
+	if f != nil {
+		unlock(f)
+		f.Close()
+	}
+
+	storageMutex.Unlock()
+}
+
+// loadShared loads the storage for reading, waiting for the writes of the other goroutines of this process.
+// The invalid service entries are skipped
+func loadShared() (*Storage, error) {
+	storageMutex.RLock()
+	defer storageMutex.RUnlock()
+	s, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	skipInvalid(s)
+	return s, nil
+}
@@ -0,0 +1,12 @@
+package storage
+
+import (
+	"os"
+)
+
+// tryLock doesn't lock on windows, where flock is not available
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) {}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LoadMerged loads several storage files in order, e.g. a shared one and a personal one, and merges their services,
+// the later files taking precedence. Missing files and invalid service entries are skipped, and files with different versions are an error.
+// The merged storage is a read-only view, the files are never modified
+func LoadMerged(paths ...string) (*Storage, error) {
+	merged := &Storage{Services: map[string]Service{}}
+	from := ""
+	for _, p := range paths {
+		s, err := readStorageFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if s == nil {
+			continue
+		}
+
+		if s.Version == "" {
+			s.Version = version
+		}
+
+		if from != "" && s.Version != merged.Version {
+			return nil, fmt.Errorf("the storage file %s has the version %s, but %s has the version %s", p, s.Version, from, merged.Version)
+		}
+
+		from = p
+		merged.Version = s.Version
+		for name, svc := range s.Services {
+			merged.Services[name] = svc
+		}
+	}
+
+	if merged.Version == "" {
+		merged.Version = version
+	}
+
+	if err := migrate(merged); err != nil {
+		return nil, err
+	}
+
+	for name, svc := range merged.Services {
+		if err := svc.validate(); err != nil {
+			log.Warnf("ignoring the service entry %s: %s", name, err)
+			delete(merged.Services, name)
+		}
+	}
+
+	return merged, nil
+}
+
+// readStorageFile reads a storage file without upgrading it, or returns nil if it doesn't exist
+func readStorageFile(p string) (*Storage, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading the storage file %s: %s", p, err.Error())
+	}
+
+	var s Storage
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("error unmarshalling the storage file %s: %s", p, err.Error())
+	}
+
+	if s.Checksum != "" {
+		checksum, err := s.checksum()
+		if err != nil {
+			return nil, err
+		}
+
+		if checksum != s.Checksum {
+			return nil, fmt.Errorf("%s: %w", p, ErrStorageCorrupt)
+		}
+	}
+
+	return &s, nil
+}
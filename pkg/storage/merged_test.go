@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMerged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	shared := filepath.Join(dir, "shared")
+	personal := filepath.Join(dir, "personal")
+	files := map[string]string{
+		shared:   "version: \"1.0\"\nservices:\n  team/db/:\n    folder: /db\n  team/api/:\n    folder: /shared/api\n",
+		personal: "version: \"1.0\"\nservices:\n  team/api/:\n    folder: /home/api\n",
+	}
+	for p, content := range files {
+		if err := ioutil.WriteFile(p, []byte(content), 0444); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := LoadMerged(shared, filepath.Join(dir, "missing"), personal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Services) != 2 || s.Services["team/db/"].Folder != "/db" || s.Services["team/api/"].Folder != "/home/api" {
+		t.Errorf("wrong services: %+v", s.Services)
+	}
+
+	if s.Version != version {
+		t.Errorf("%s != %s", s.Version, version)
+	}
+
+	older := filepath.Join(dir, "older")
+	if err := ioutil.WriteFile(older, []byte("version: \"0.9\"\nservices:\n  team/web/:\n    folder: /web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadMerged(shared, older); err == nil || !strings.Contains(err.Error(), "has the version 0.9") {
+		t.Errorf("conflicting versions were accepted: %v", err)
+	}
+
+	s, err = LoadMerged(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Services) != 0 {
+		t.Errorf("services were loaded from a missing file: %+v", s.Services)
+	}
+}
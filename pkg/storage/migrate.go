@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// migration transforms a storage file from a version to the next one
+type migration struct {
+	from  string
+	to    string
+	apply func(s *Storage) error
+}
+
+// migrations are applied in order to upgrade the storage files written by older versions
+var migrations = []migration{}
+
+// migrate upgrades the storage to the current version
+func migrate(s *Storage) error {
+	if s.Version == "" {
+		s.Version = version
+	}
+
+	for _, m := range migrations {
+		if s.Version != m.from {
+			continue
+		}
+
+		if err := m.apply(s); err != nil {
+			return fmt.Errorf("error migrating the storage file from version %s to %s: %s", m.from, m.to, err.Error())
+		}
+		s.Version = m.to
+	}
+
+	if s.Version == version {
+		return nil
+	}
+
+	newer, err := isNewerMajor(s.Version, version)
+	if err != nil {
+		return fmt.Errorf("the storage file version %s is not valid: %w", s.Version, err)
+	}
+
+	if newer {
+		return fmt.Errorf("%w: the storage file version %s is newer than the version %s supported by this cnd binary, please upgrade cnd", ErrIncompatibleVersion, s.Version, version)
+	}
+
+	// files from a newer minor version only add fields, they are read as they are
+	if newer, _ := isNewerVersion(s.Version, version); newer {
+		return nil
+	}
+
+	return fmt.Errorf("the storage file version %s is not supported", s.Version)
+}
+
+// IsCompatible returns true if the storage file can be read by this cnd binary, that is, if it isn't from a newer major version.
+// Files from a newer minor version only add fields and files without a version were written by older versions
+func (s *Storage) IsCompatible() (bool, error) {
+	if s.Version == "" {
+		return true, nil
+	}
+
+	newer, err := isNewerMajor(s.Version, version)
+	if err != nil {
+		return false, err
+	}
+
+	return !newer, nil
+}
+
+// storageVersion is the major.minor version of the storage file format
+type storageVersion struct {
+	major int
+	minor int
+}
+
+// newerThan returns true if v is newer than o
+func (v storageVersion) newerThan(o storageVersion) bool {
+	if v.major != o.major {
+		return v.major > o.major
+	}
+
+	return v.minor > o.minor
+}
+
+// isNewerVersion returns true if the major.minor version a is newer than b
+func isNewerVersion(a, b string) (bool, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return false, err
+	}
+
+	vb, err := parseVersion(b)
+	if err != nil {
+		return false, err
+	}
+
+	return va.newerThan(vb), nil
+}
+
+// isNewerMajor returns true if the major of the major.minor version a is newer than the major of b
+func isNewerMajor(a, b string) (bool, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return false, err
+	}
+
+	vb, err := parseVersion(b)
+	if err != nil {
+		return false, err
+	}
+
+	return va.major > vb.major, nil
+}
+
+func parseVersion(v string) (storageVersion, error) {
+	parts := strings.Split(v, ".")
+	if len(parts) != 2 {
+		return storageVersion{}, fmt.Errorf("'%s' is not a major.minor version", v)
+	}
+
+	var numbers [2]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return storageVersion{}, fmt.Errorf("'%s' is not a major.minor version", v)
+		}
+		numbers[i] = n
+	}
+
+	return storageVersion{major: numbers[0], minor: numbers[1]}, nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	previous := migrations
+	defer func() { migrations = previous }()
+
+	migrations = []migration{
+		{from: "0.8", to: "0.9", apply: func(s *Storage) error {
+			s.Services["renamed"] = s.Services["old"]
+			delete(s.Services, "old")
+			return nil
+		}},
+		{from: "0.9", to: version, apply: func(s *Storage) error { return nil }},
+		{from: "0.7", to: "0.8", apply: func(s *Storage) error { return fmt.Errorf("broken") }},
+	}
+
+	var tests = []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{name: "current", version: version},
+		{name: "unversioned", version: ""},
+		{name: "older", version: "0.8"},
+		{name: "newer-minor", version: "1.1"},
+		{name: "newer", version: "2.0", expected: "newer than the version"},
+		{name: "invalid", version: "latest", expected: "not valid"},
+		{name: "unknown", version: "0.1", expected: "not supported"},
+		{name: "failed", version: "0.7", expected: "broken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Storage{Version: tt.version, Services: map[string]Service{"old": {Folder: "/folder"}}}
+			err := migrate(s)
+			if tt.expected != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expected) {
+					t.Errorf("wrong error, expected '%s': %v", tt.expected, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Version != version && s.Version != tt.version {
+				t.Errorf("%s != %s", s.Version, version)
+			}
+		})
+	}
+
+	s := &Storage{Version: "0.8", Services: map[string]Service{"old": {Folder: "/folder"}}}
+	if err := migrate(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Services["renamed"].Folder != "/folder" {
+		t.Errorf("migration wasn't applied: %+v", s.Services)
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	var tests = []struct {
+		name     string
+		version  string
+		expected bool
+		err      bool
+	}{
+		{name: "current", version: version, expected: true},
+		{name: "unversioned", version: "", expected: true},
+		{name: "older", version: "0.8", expected: true},
+		{name: "newer-minor", version: "1.1", expected: true},
+		{name: "newer-major", version: "2.0"},
+		{name: "invalid", version: "latest", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Storage{Version: tt.version}
+			compatible, err := s.IsCompatible()
+			if tt.err {
+				if err == nil {
+					t.Errorf("invalid version was accepted")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if compatible != tt.expected {
+				t.Errorf("%t != %t", compatible, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadIncompatibleVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte("version: \"2.0\"\nservices:\n  project/api/:\n    folder: /api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := load(); !errors.Is(err, ErrIncompatibleVersion) {
+		t.Errorf("the newer version was loaded: %v", err)
+	}
+}
+
+func TestLoadNewerMinorVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte("version: \"1.1\"\nservices:\n  project/api/:\n    folder: /api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Services["project/api/"].Folder != "/api" {
+		t.Errorf("the service wasn't loaded: %+v", s.Services)
+	}
+}
+
+func TestLoadInvalidVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte("version: garbage\nservices:\n  project/api/:\n    folder: /api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := load(); err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Errorf("the invalid version was loaded: %v", err)
+	}
+}
@@ -1,43 +1,181 @@
 package storage
 
 import (
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/okteto/cnd/pkg/model"
+	log "github.com/sirupsen/logrus"
 	yaml "gopkg.in/yaml.v2"
 )
 
 const (
 	version = "1.0"
+
+	// StatusSynced indicates the local and remote files of a service are synchronized
+	StatusSynced = "synced"
+
+	// StatusSyncing indicates the files of a service are being synchronized
+	StatusSyncing = "syncing"
+
+	// StatusError indicates the synchronization of a service failed
+	StatusError = "error"
 )
 
 var (
 	stPath string
+
+	// kubeContext is the kubernetes context of the service entries, to tell apart namespaces with the same name in different clusters
+	kubeContext string
+
+	// RecoverCorrupted makes a corrupted storage file to be backed up and replaced by an empty storage, instead of failing
+	RecoverCorrupted = false
+
+	// AuditFunc is called after Insert, Stop and Delete change the storage, with the operation ("insert", "stop" or "delete")
+	// and the full name of the service entry, e.g. to keep a log of the changes. It's called holding the storage lock,
+	// so it must not call the functions of this package
+	AuditFunc func(op string, key string)
+
+	// loadAttempts and loadBackoff retry the transient errors reading the storage file before modifying it, e.g. on NFS.
+	// The backoff doubles after each attempt
+	loadAttempts = 3
+	loadBackoff  = 100 * time.Millisecond
+
+	// errStorageRead indicates the storage file couldn't be read, which may be transient unlike a corrupted file
+	errStorageRead = fmt.Errorf("error reading the storage file")
+
+	// reachableTimeout is how long to wait for the syncthing of a service to accept a connection
+	reachableTimeout = 500 * time.Millisecond
+
 	// ErrAlreadyRunning indicates a "cnd up" command is already running
 	ErrAlreadyRunning = fmt.Errorf("up-already-running")
+
+	// ErrFolderConflict indicates the folder of a service is already synched by another service with a different syncthing host
+	ErrFolderConflict = fmt.Errorf("the folder is already synched by another service")
+
+	// FailOnFolderConflict makes Insert to fail with ErrFolderConflict, instead of warning, when the folder of a service is already synched by another one
+	FailOnFolderConflict = false
+
+	// ErrIncompatibleVersion indicates the storage file was written by a newer cnd binary
+	ErrIncompatibleVersion = fmt.Errorf("incompatible storage file version")
+
+	// ErrStorageCorrupt indicates the services of the storage file don't match its checksum
+	ErrStorageCorrupt = fmt.Errorf("the storage file is corrupted, its checksum doesn't match its services")
 )
 
 //Storage represents the cli state
 type Storage struct {
 	path     string
 	Version  string             `yaml:"version,omitempty"`
+	Checksum string             `yaml:"checksum,omitempty"`
 	Services map[string]Service `yaml:"services,omitempty"`
 }
 
 //Service represents the information about a cnd service
 type Service struct {
-	Folder    string `yaml:"folder,omitempty"`
-	Syncthing string `yaml:"syncthing,omitempty"`
+	Folder string `yaml:"folder,omitempty"`
+
+	// Target is the path of the main mount in the container
+	Target string `yaml:"target,omitempty"`
+
+	// Manifest is the absolute path of the manifest file of the service, to read it again
+	Manifest string `yaml:"manifest,omitempty"`
+
+	// Syncthing is the host of the syncthing api. It can reference environment variables, e.g. ${CND_SYNCTHING_HOST},
+	// that are expanded by ResolvedHost when the entry is read
+	Syncthing string            `yaml:"syncthing,omitempty"`
+	Metadata  map[string]string `yaml:"metadata,omitempty"`
+	Config    map[string]string `yaml:"config,omitempty"`
+	StartedAt time.Time         `yaml:"started_at,omitempty"`
+	Status    string            `yaml:"status,omitempty"`
+	Context   string            `yaml:"context,omitempty"`
+
+	// OriginalCommand and OriginalArgs are the command and args of the container before it was swapped
+	OriginalCommand []string `yaml:"original_command,omitempty"`
+	OriginalArgs    []string `yaml:"original_args,omitempty"`
+}
+
+// ServiceEntry is a service entry with its name parsed
+type ServiceEntry struct {
+	Name       string
+	Context    string
+	Namespace  string
+	Deployment string
+	Container  string
+	Service
+}
+
+// DeploymentRef identifies a deployment with at least one swapped container
+type DeploymentRef struct {
+	Context    string
+	Namespace  string
+	Deployment string
 }
 
 func init() {
 	stPath = path.Join(model.GetCNDHome(), ".state")
 }
+
+// StoragePath returns the path of the storage file
+func StoragePath() string {
+	return stPath
+}
+
+// SetStoragePath changes the storage file, e.g. to isolate tests from the CND home
+func SetStoragePath(path string) {
+	stPath = path
+}
+
+// SetContext sets the kubernetes context of the service entries. Entries written without a context are still found
+func SetContext(context string) {
+	kubeContext = context
+}
+
+// load reads the storage from the backend, including the invalid service entries
 func load() (*Storage, error) {
+	return backend.Load()
+}
+
+// skipInvalid removes the invalid service entries, e.g. hand-edited ones, from a storage loaded for reading.
+// The storages loaded for update keep them, so saving never erases them, e.g. before running cnd down
+func skipInvalid(s *Storage) {
+	for name, svc := range s.Services {
+		if err := svc.validate(); err != nil {
+			log.Warnf("ignoring the service entry %s: %s", name, err)
+			delete(s.Services, name)
+		}
+	}
+}
+
+// loadForUpdate loads the storage to modify it, retrying the errors reading the storage file.
+// Unmarshalling and checksum errors fail right away, since they won't fix themselves
+func loadForUpdate() (*Storage, error) {
+	backoff := loadBackoff
+	for attempt := 1; ; attempt++ {
+		s, err := load()
+		if err == nil || !errors.Is(err, errStorageRead) || attempt >= loadAttempts {
+			return s, err
+		}
+
+		log.Debugf("failed to load the storage, retrying: %s", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// loadFile reads the storage file, upgrading it if it was written by an older version
+func loadFile() (*Storage, error) {
 	var s Storage
 	s.path = stPath
 	s.Version = version
@@ -47,50 +185,249 @@ func load() (*Storage, error) {
 	}
 	bytes, err := ioutil.ReadFile(stPath)
 	if err != nil {
-		return nil, fmt.Errorf("error reading the storage file: %s", err.Error())
+		return nil, fmt.Errorf("%w: %s", errStorageRead, err.Error())
 	}
 	err = yaml.Unmarshal(bytes, &s)
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling the storage file: %s", err.Error())
+		if !RecoverCorrupted {
+			return nil, fmt.Errorf("error unmarshalling the storage file: %s", err.Error())
+		}
+
+		return recoverCorrupted(err)
+	}
+
+	// storage files written by older versions have no checksum
+	if s.Checksum != "" {
+		checksum, err := s.checksum()
+		if err != nil {
+			return nil, err
+		}
+
+		if checksum != s.Checksum {
+			if !RecoverCorrupted {
+				return nil, ErrStorageCorrupt
+			}
+
+			return recoverCorrupted(ErrStorageCorrupt)
+		}
+	}
+
+	compatible, err := s.IsCompatible()
+	if err != nil {
+		return nil, fmt.Errorf("the storage file version %s is not valid: %w", s.Version, err)
+	}
+
+	if !compatible {
+		return nil, fmt.Errorf("%w: the storage file version %s is newer than the version %s supported by this cnd binary, please upgrade cnd", ErrIncompatibleVersion, s.Version, version)
+	}
+
+	if err := migrate(&s); err != nil {
+		return nil, err
 	}
 	return &s, nil
 }
 
+// recoverCorrupted backs up the corrupted storage file and returns an empty storage
+func recoverCorrupted(cause error) (*Storage, error) {
+	backup := stPath + ".corrupt"
+	if err := os.Rename(stPath, backup); err != nil {
+		return nil, fmt.Errorf("error backing up the corrupted storage file: %s", err.Error())
+	}
+
+	log.Warnf("the storage file is corrupted, it was moved to %s: %s", backup, cause)
+	return &Storage{path: stPath, Version: version, Services: map[string]Service{}}, nil
+}
+
 //Insert inserts a new service entry
 func Insert(namespace string, dev *model.Dev, host string) error {
-	s, err := load()
+	return InsertWithOriginal(namespace, dev, host, nil, nil)
+}
+
+// InsertOutcome is what inserting a service entry does to the storage
+type InsertOutcome int
+
+const (
+	// InsertCreated indicates a new service entry is created
+	InsertCreated InsertOutcome = iota
+
+	// InsertUpdated indicates an existing service entry without a syncthing host is replaced
+	InsertUpdated
+
+	// InsertUnchanged indicates the service entry already exists with the same folder and host
+	InsertUnchanged
+
+	// InsertAlreadyRunning indicates the service entry already exists with another syncthing host
+	InsertAlreadyRunning
+)
+
+func (o InsertOutcome) String() string {
+	switch o {
+	case InsertCreated:
+		return "created"
+	case InsertUpdated:
+		return "updated"
+	case InsertUnchanged:
+		return "unchanged"
+	case InsertAlreadyRunning:
+		return "already running"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(o))
+}
+
+// InsertWithOriginal inserts a new service entry, recording the command and args of the container before it was swapped
+func InsertWithOriginal(namespace string, dev *model.Dev, host string, command, args []string) error {
+	l, err := acquireLock()
 	if err != nil {
 		return err
 	}
+	defer releaseLock(l)
 
-	fullName := getFullName(namespace, dev)
-	svc, err := newService(dev.Mount.Source, host)
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	outcome, err := s.insert(namespace, dev, host, command, args)
 	if err != nil {
 		return err
 	}
 
-	if svc2, ok := s.Services[fullName]; ok {
-		if svc2 == svc {
-			return nil
+	switch outcome {
+	case InsertUnchanged:
+		return nil
+	case InsertAlreadyRunning:
+		return ErrAlreadyRunning
+	}
+
+	if err := s.save(); err != nil {
+		return err
+	}
+
+	audit("insert", s.findName(namespace, dev))
+	return nil
+}
+
+// PreviewInsert returns what Insert would do with the service entry, without changing the storage file, e.g. for a dry run
+func PreviewInsert(namespace string, dev *model.Dev, host string) (InsertOutcome, error) {
+	s, err := loadShared()
+	if err != nil {
+		return InsertCreated, err
+	}
+
+	return s.insert(namespace, dev, host, nil, nil)
+}
+
+// insert adds the service entry to s, and returns the outcome. s is only modified if the outcome is InsertCreated or InsertUpdated
+func (s *Storage) insert(namespace string, dev *model.Dev, host string, command, args []string) (InsertOutcome, error) {
+	fullName := getFullName(namespace, dev)
+	svc, err := newService(dev.MainMount().Source, host)
+	if err != nil {
+		return InsertCreated, err
+	}
+	svc.Target = dev.MainMount().Target
+	svc.Manifest = dev.ManifestPath()
+	svc.Context = kubeContext
+	svc.Config = dev.AreaHashes()
+	svc.OriginalCommand = command
+	svc.OriginalArgs = args
+
+	existing := s.findName(namespace, dev)
+	if err := s.checkFolderConflict(existing, svc); err != nil {
+		if FailOnFolderConflict {
+			return InsertCreated, err
+		}
+
+		log.Warn(err)
+	}
+
+	outcome := InsertCreated
+
+	// an entry written without a context is moved to the current one
+	if svc2, ok := s.Services[existing]; ok {
+		if svc2.Folder == svc.Folder && svc2.Syncthing == svc.Syncthing {
+			return InsertUnchanged, nil
 		}
 
 		if svc2.Syncthing != "" {
-			return ErrAlreadyRunning
+			return InsertAlreadyRunning, nil
+		}
+
+		svc.Metadata = svc2.Metadata
+		if command == nil && args == nil {
+			svc.OriginalCommand = svc2.OriginalCommand
+			svc.OriginalArgs = svc2.OriginalArgs
 		}
+
+		delete(s.Services, existing)
+		outcome = InsertUpdated
 	}
 
 	s.Services[fullName] = svc
-	return s.save()
+	return outcome, nil
+}
+
+// checkFolderConflict returns ErrFolderConflict if another service syncs the folder of svc with a different syncthing host,
+// since both syncthing setups would overwrite each other's changes. Services without file synchronization are ignored
+func (s *Storage) checkFolderConflict(name string, svc Service) error {
+	if svc.Syncthing == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.Services))
+	for n := range s.Services {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		other := s.Services[n]
+		if n == name || other.Syncthing == "" || other.Folder != svc.Folder || other.Syncthing == svc.Syncthing {
+			continue
+		}
+
+		return fmt.Errorf("%w: '%s' is synched by '%s' with the host %s", ErrFolderConflict, svc.Folder, n, other.Syncthing)
+	}
+
+	return nil
 }
 
 //Get gets a service entry
 func Get(namespace string, dev *model.Dev) (*Service, error) {
-	s, err := load()
+	s, err := loadShared()
 	if err != nil {
 		return nil, err
 	}
 
-	fullName := getFullName(namespace, dev)
+	return s.get(namespace, dev)
+}
+
+// GetMany gets the service entries of several devs loading the storage once, keyed by their full names.
+// It returns an error for each dev without a service entry, or a single error if the storage can't be loaded
+func GetMany(namespace string, devs []*model.Dev) (map[string]*Service, []error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	services := map[string]*Service{}
+	var errs []error
+	for _, dev := range devs {
+		svc, err := s.get(namespace, dev)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		services[getFullName(namespace, dev)] = svc
+	}
+
+	return services, errs
+}
+
+// get returns the service entry of a dev
+func (s *Storage) get(namespace string, dev *model.Dev) (*Service, error) {
+	fullName := s.findName(namespace, dev)
 	svc, ok := s.Services[fullName]
 	if !ok {
 		return nil, fmt.Errorf("there aren't any active cloud native development environments available for '%s'", fullName)
@@ -98,77 +435,865 @@ func Get(namespace string, dev *model.Dev) (*Service, error) {
 	return &svc, nil
 }
 
-//Stop marks a service entry as stopped
-func Stop(namespace string, dev *model.Dev) error {
-	s, err := load()
+// Reup reads again the manifest of the service entry of a container, e.g. to restart a stopped service
+func Reup(namespace, deployment, container string) (*model.Dev, error) {
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: deployment, Container: container}}}
+	svc, err := Get(namespace, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	if svc.Manifest == "" {
+		return nil, fmt.Errorf("the manifest of '%s' is unknown, it was activated by an older version of cnd", getFullName(namespace, dev))
+	}
+
+	d, err := model.ReadDev(svc.Manifest)
+	if errors.Is(err, model.ErrManifestNotFound) {
+		return nil, fmt.Errorf("the manifest %s of '%s' doesn't exist, it was moved or deleted", svc.Manifest, getFullName(namespace, dev))
+	}
+
+	return d, err
+}
+
+// Exists returns true if there is a service entry for the dev. Unlike Get, a missing entry is not an error
+func Exists(namespace string, dev *model.Dev) (bool, error) {
+	s, err := loadShared()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := s.Services[s.findName(namespace, dev)]
+	return ok, nil
+}
+
+// GetByDeployment returns the service entries of every swapped container of a deployment, sorted by container
+func GetByDeployment(namespace, deployment string) ([]Service, error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range s.Services {
+		if c, ns, d, _, err := parseFullName(name); err == nil && inContext(c) && ns == namespace && d == deployment {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("there aren't any active cloud native development environments available for the deployment '%s/%s'", namespace, deployment)
+	}
+
+	sort.Strings(names)
+	services := make([]Service, len(names))
+	for i, name := range names {
+		services[i] = s.Services[name]
+	}
+
+	return services, nil
+}
+
+// UpdateHost changes the syncthing host of an existing service entry, keeping the rest of it
+func UpdateHost(namespace string, dev *model.Dev, host string) error {
+	l, err := acquireLock()
 	if err != nil {
 		return err
 	}
+	defer releaseLock(l)
 
-	fullName := getFullName(namespace, dev)
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	fullName := s.findName(namespace, dev)
 	svc, ok := s.Services[fullName]
-	if ok {
-		svc.Syncthing = ""
-		s.Services[fullName] = svc
-		return s.save()
+	if !ok {
+		return fmt.Errorf("there aren't any active cloud native development environments available for '%s'", fullName)
 	}
-	return nil
+
+	svc.Syncthing = host
+	s.Services[fullName] = svc
+	return s.save()
 }
 
-//Delete deletes a service entry
-func Delete(namespace string, dev *model.Dev) error {
-	s, err := load()
+// Relocate changes the local folder of an existing service entry, e.g. after moving the checkout, keeping the rest of it
+func Relocate(namespace string, dev *model.Dev, newFolder string) error {
+	folder, err := fixPath(newFolder)
 	if err != nil {
 		return err
 	}
 
-	fullName := getFullName(namespace, dev)
-	delete(s.Services, fullName)
+	l, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	fullName := s.findName(namespace, dev)
+	svc, ok := s.Services[fullName]
+	if !ok {
+		return fmt.Errorf("there aren't any active cloud native development environments available for '%s'", fullName)
+	}
+
+	svc.Folder = folder
+	s.Services[fullName] = svc
 	return s.save()
 }
 
-//All returns the active cnd services
-func All() map[string]Service {
-	s, err := load()
+// SetStatus updates the synchronization status of a service
+func SetStatus(namespace string, dev *model.Dev, status string) error {
+	switch status {
+	case StatusSynced, StatusSyncing, StatusError:
+	default:
+		return fmt.Errorf("'%s' is not a valid status, must be one of '%s', '%s' or '%s'", status, StatusSynced, StatusSyncing, StatusError)
+	}
+
+	l, err := acquireLock()
 	if err != nil {
-		return nil
+		return err
 	}
+	defer releaseLock(l)
 
-	return s.Services
-}
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
 
-func (s *Storage) save() error {
+	fullName := s.findName(namespace, dev)
+	svc, ok := s.Services[fullName]
+	if !ok {
+		return fmt.Errorf("there aren't any active cloud native development environments available for '%s'", fullName)
+	}
 
-	bytes, err := yaml.Marshal(s)
+	svc.Status = status
+	s.Services[fullName] = svc
+	return s.save()
+}
+
+//Stop marks a service entry as stopped
+func Stop(namespace string, dev *model.Dev) error {
+	l, err := acquireLock()
 	if err != nil {
-		return fmt.Errorf("error marshalling storage: %s", err.Error())
+		return err
 	}
-	err = ioutil.WriteFile(s.path, bytes, 0644)
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
 	if err != nil {
-		return fmt.Errorf("error writing storage: %s", err.Error())
+		return err
+	}
+
+	fullName := s.findName(namespace, dev)
+	svc, ok := s.Services[fullName]
+	if ok {
+		svc.Syncthing = ""
+		s.Services[fullName] = svc
+		if err := s.save(); err != nil {
+			return err
+		}
+
+		audit("stop", fullName)
 	}
 	return nil
 }
 
-func fixPath(originalPath string) (string, error) {
-	if filepath.IsAbs(originalPath) {
-		return originalPath, nil
+// StopAll marks every service entry as stopped, keeping their folders
+func StopAll() error {
+	l, err := acquireLock()
+	if err != nil {
+		return err
 	}
-	folder, err := os.Getwd()
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
 	if err != nil {
-		return "", err
+		return err
 	}
-	return path.Join(folder, originalPath), nil
+
+	var stopped []string
+	for name, svc := range s.Services {
+		if svc.Syncthing == "" {
+			continue
+		}
+
+		svc.Syncthing = ""
+		s.Services[name] = svc
+		stopped = append(stopped, name)
+	}
+
+	if len(stopped) == 0 {
+		return nil
+	}
+
+	if err := s.save(); err != nil {
+		return err
+	}
+
+	sort.Strings(stopped)
+	for _, name := range stopped {
+		audit("stop", name)
+	}
+
+	return nil
 }
 
-func newService(folder, host string) (Service, error) {
-	absFolder, err := fixPath(folder)
+// SetMetadata sets a metadata key of a service entry
+func SetMetadata(namespace string, dev *model.Dev, key, value string) error {
+	if key == "" {
+		return fmt.Errorf("metadata key cannot be empty")
+	}
+
+	l, err := acquireLock()
 	if err != nil {
-		return Service{}, err
+		return err
 	}
-	return Service{Folder: absFolder, Syncthing: host}, nil
-}
+	defer releaseLock(l)
 
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	fullName := s.findName(namespace, dev)
+	svc, ok := s.Services[fullName]
+	if !ok {
+		return fmt.Errorf("there aren't any active cloud native development environments available for '%s'", fullName)
+	}
+
+	if svc.Metadata == nil {
+		svc.Metadata = map[string]string{}
+	}
+	svc.Metadata[key] = value
+	s.Services[fullName] = svc
+	return s.save()
+}
+
+// GetMetadata returns a metadata key of a service entry, and whether the key was set
+func GetMetadata(namespace string, dev *model.Dev, key string) (string, bool, error) {
+	if key == "" {
+		return "", false, fmt.Errorf("metadata key cannot be empty")
+	}
+
+	svc, err := Get(namespace, dev)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := svc.Metadata[key]
+	return value, ok, nil
+}
+
+//Delete deletes a service entry
+func Delete(namespace string, dev *model.Dev) error {
+	l, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return err
+	}
+
+	fullName := s.findName(namespace, dev)
+	_, ok := s.Services[fullName]
+	delete(s.Services, fullName)
+	if err := s.save(); err != nil {
+		return err
+	}
+
+	if ok {
+		audit("delete", fullName)
+	}
+	return nil
+}
+
+// audit calls AuditFunc, if set
+func audit(op, key string) {
+	if AuditFunc != nil {
+		AuditFunc(op, key)
+	}
+}
+
+// DeleteNamespace deletes every service entry of a namespace, and returns how many entries were deleted
+func DeleteNamespace(namespace string) (int, error) {
+	l, err := acquireLock()
+	if err != nil {
+		return 0, err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for name := range s.Services {
+		if inNamespace(name, namespace) {
+			delete(s.Services, name)
+			deleted++
+		}
+	}
+
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	return deleted, s.save()
+}
+
+// Compact rewrites the service keys with keyFn, e.g. after a change of getFullName, dropping the entries for which it returns false.
+// It returns how many keys were changed, and fails without saving if two entries end up with the same key
+func Compact(keyFn func(old string) (string, bool)) (int, error) {
+	l, err := acquireLock()
+	if err != nil {
+		return 0, err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return 0, err
+	}
+
+	services := map[string]Service{}
+	sources := map[string]string{}
+	remapped := 0
+	changed := false
+	for name, svc := range s.Services {
+		key, ok := keyFn(name)
+		if !ok {
+			changed = true
+			continue
+		}
+
+		if other, ok := sources[key]; ok {
+			return 0, fmt.Errorf("services '%s' and '%s' are both compacted to '%s'", other, name, key)
+		}
+
+		if key != name {
+			remapped++
+			changed = true
+		}
+
+		sources[key] = name
+		services[key] = svc
+	}
+
+	if !changed {
+		return 0, nil
+	}
+
+	s.Services = services
+	return remapped, s.save()
+}
+
+// Rehome moves the folder of every service entry under oldBase to newBase, and returns how many entries changed
+func Rehome(oldBase, newBase string) (int, error) {
+	oldBase, err := fixPath(oldBase)
+	if err != nil {
+		return 0, err
+	}
+
+	newBase, err = fixPath(newBase)
+	if err != nil {
+		return 0, err
+	}
+
+	oldBase = path.Clean(oldBase)
+	newBase = path.Clean(newBase)
+
+	l, err := acquireLock()
+	if err != nil {
+		return 0, err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for name, svc := range s.Services {
+		if !isUnder(svc.Folder, oldBase) {
+			continue
+		}
+
+		svc.Folder = path.Join(newBase, strings.TrimPrefix(svc.Folder, oldBase))
+		s.Services[name] = svc
+		changed++
+	}
+
+	if changed == 0 {
+		return 0, nil
+	}
+
+	return changed, s.save()
+}
+
+// Reset replaces the storage with an empty one, without reading it, so it also works when the storage file is corrupted
+func Reset() error {
+	l, err := acquireLock()
+	if err != nil {
+		return err
+	}
+	defer releaseLock(l)
+
+	s := &Storage{path: stPath, Version: version, Services: map[string]Service{}}
+	return s.save()
+}
+
+//All returns the active cnd services
+func All() map[string]Service {
+	s, err := loadShared()
+	if err != nil {
+		return nil
+	}
+
+	return s.Services
+}
+
+// List returns the active cnd services sorted by name
+func List() []ServiceEntry {
+	services := All()
+	entries := make([]ServiceEntry, 0, len(services))
+	for name, svc := range services {
+		context, namespace, deployment, container, err := parseFullName(name)
+		if err != nil {
+			log.Debugf("ignoring service entry: %s", err)
+			continue
+		}
+
+		entries = append(entries, ServiceEntry{
+			Name:       name,
+			Context:    context,
+			Namespace:  namespace,
+			Deployment: deployment,
+			Container:  container,
+			Service:    svc,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// ServicesUnder returns the service entries whose folder is dir or is nested under it, sorted by name.
+// A relative dir is resolved against the current folder
+func ServicesUnder(dir string) []ServiceEntry {
+	entries := []ServiceEntry{}
+	base, err := fixPath(dir)
+	if err != nil {
+		log.Debugf("failed to resolve %s: %s", dir, err)
+		return entries
+	}
+
+	base = path.Clean(base)
+	for _, e := range List() {
+		if isUnder(path.Clean(e.Folder), base) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// isUnder returns true if the clean folder is equal to or nested under the clean base folder
+func isUnder(folder, base string) bool {
+	return folder == base || strings.HasPrefix(folder, strings.TrimSuffix(base, "/")+"/")
+}
+
+// Deployments returns the deployments of the active cnd services, once per deployment however many containers are swapped,
+// sorted by context, namespace and name. Service names that can't be parsed are skipped
+func Deployments() []DeploymentRef {
+	seen := map[DeploymentRef]bool{}
+	refs := []DeploymentRef{}
+	for name := range All() {
+		context, namespace, deployment, _, err := parseFullName(name)
+		if err != nil {
+			log.Debugf("ignoring service entry: %s", err)
+			continue
+		}
+
+		ref := DeploymentRef{Context: context, Namespace: namespace, Deployment: deployment}
+		if seen[ref] {
+			continue
+		}
+
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Context != refs[j].Context {
+			return refs[i].Context < refs[j].Context
+		}
+
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+
+		return refs[i].Deployment < refs[j].Deployment
+	})
+
+	return refs
+}
+
+// AllInNamespace returns the active cnd services of a namespace, keyed like in All
+func AllInNamespace(namespace string) map[string]Service {
+	services := All()
+	if services == nil {
+		return nil
+	}
+
+	result := map[string]Service{}
+	for name, svc := range services {
+		if inNamespace(name, namespace) {
+			result[name] = svc
+		}
+	}
+
+	return result
+}
+
+// String returns a single line summary of the service for logs, e.g. folder=/home/user/api host=localhost:60000
+func (s Service) String() string {
+	return fmt.Sprintf("folder=%s host=%s", s.Folder, s.Syncthing)
+}
+
+// ResolvedHost returns the syncthing host with its environment variables expanded.
+// An unset variable expands to an empty string, so a host that is only an unset variable resolves to an empty host
+func (s Service) ResolvedHost() string {
+	return os.ExpandEnv(s.Syncthing)
+}
+
+// IsReachable returns true if the syncthing of the service accepts connections
+func (s Service) IsReachable() bool {
+	host := s.ResolvedHost()
+	if host == "" {
+		return false
+	}
+
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	conn, err := net.DialTimeout("tcp", host, reachableTimeout)
+	if err != nil {
+		log.Debugf("syncthing of %s is not reachable: %s", s.Folder, err)
+		return false
+	}
+
+	conn.Close()
+	return true
+}
+
+// Prune marks the service entries with an unreachable syncthing as stopped, and returns their names
+func Prune() ([]string, error) {
+	l, err := acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for name, svc := range s.Services {
+		if svc.Syncthing == "" || svc.IsReachable() {
+			continue
+		}
+
+		svc.Syncthing = ""
+		s.Services[name] = svc
+		pruned = append(pruned, name)
+	}
+
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(pruned)
+	return pruned, s.save()
+}
+
+// GarbageCollect deletes the stopped service entries whose folder doesn't exist anymore, and returns their names
+func GarbageCollect() ([]string, error) {
+	l, err := acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(l)
+
+	s, err := loadForUpdate()
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for name, svc := range s.Services {
+		if svc.Syncthing != "" {
+			continue
+		}
+
+		if _, err := os.Stat(svc.Folder); !os.IsNotExist(err) {
+			continue
+		}
+
+		delete(s.Services, name)
+		deleted = append(deleted, name)
+	}
+
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(deleted)
+	return deleted, s.save()
+}
+
+// Reconcile deletes the service entries of the current context whose container doesn't exist anymore, and returns their names.
+// exists is wired by the caller to its kubernetes client. It's called without holding the storage lock, and nothing is deleted if it fails.
+// The entries changed since they were checked, e.g. inserted again by cnd up, are kept
+func Reconcile(exists func(namespace, deployment, container string) (bool, error)) ([]string, error) {
+	s, err := loadShared()
+	if err != nil {
+		return nil, err
+	}
+
+	missing := map[string]Service{}
+	for name, svc := range s.Services {
+		context, namespace, deployment, container, err := parseFullName(name)
+		if err != nil {
+			log.Debugf("ignoring service entry: %s", err)
+			continue
+		}
+
+		if !inContext(context) {
+			continue
+		}
+
+		ok, err := exists(namespace, deployment, container)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check the service entry %s: %s", name, err)
+		}
+
+		if !ok {
+			missing[name] = svc
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	l, err := acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLock(l)
+
+	s, err = loadForUpdate()
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for name, checked := range missing {
+		if svc, ok := s.Services[name]; ok && reflect.DeepEqual(svc, checked) {
+			delete(s.Services, name)
+			deleted = append(deleted, name)
+		}
+	}
+
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(deleted)
+	return deleted, s.save()
+}
+
+// ConfigDriftedFrom returns whether the dev changed since the service was inserted, and the areas that changed
+func (s *Service) ConfigDriftedFrom(dev *model.Dev) (bool, []string) {
+	if len(s.Config) == 0 {
+		return false, nil
+	}
+
+	var changed []string
+	for area, hash := range dev.AreaHashes() {
+		if s.Config[area] != hash {
+			changed = append(changed, area)
+		}
+	}
+
+	sort.Strings(changed)
+	return len(changed) > 0, changed
+}
+
+// save writes the storage to the backend
+func (s *Storage) save() error {
+	return backend.Save(s)
+}
+
+// saveFile writes the storage file, with the checksum of its services
+func (s *Storage) saveFile() error {
+	checksum, err := s.checksum()
+	if err != nil {
+		return err
+	}
+	s.Checksum = checksum
+
+	bytes, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error marshalling storage: %s", err.Error())
+	}
+
+	// the state is written to a temporal file and renamed, so a partial write never replaces it
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error writing storage: %s", err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeStorage(tmp, bytes); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing storage: %s", err.Error())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing storage: %s", err.Error())
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("error writing storage: %s", err.Error())
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing storage: %s", err.Error())
+	}
+	return nil
+}
+
+// checksum returns the sha256 of the marshalled services. yaml sorts the map keys, so it's deterministic
+func (s *Storage) checksum() (string, error) {
+	b, err := yaml.Marshal(s.Services)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling storage: %s", err.Error())
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// writeStorage writes the marshalled storage to the temporal file
+var writeStorage = func(f *os.File, b []byte) error {
+	_, err := f.Write(b)
+	return err
+}
+
+func fixPath(originalPath string) (string, error) {
+	if filepath.IsAbs(originalPath) {
+		return originalPath, nil
+	}
+	folder, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(folder, originalPath), nil
+}
+
+func newService(folder, host string) (Service, error) {
+	if folder == "" {
+		return Service{}, fmt.Errorf("the folder of the service entry cannot be empty")
+	}
+
+	absFolder, err := fixPath(folder)
+	if err != nil {
+		return Service{}, err
+	}
+	return Service{Folder: canonicalFolder(absFolder), Syncthing: host, StartedAt: clock.Now()}, nil
+}
+
+// validate checks that the service entry is structurally valid
+func (svc Service) validate() error {
+	if svc.Folder == "" {
+		return fmt.Errorf("its folder is empty")
+	}
+
+	if !filepath.IsAbs(svc.Folder) && !path.IsAbs(svc.Folder) {
+		return fmt.Errorf("its folder %s is not an absolute path", svc.Folder)
+	}
+
+	return nil
+}
+
+// getFullName returns the name of the service entry of a dev in the current context. Each segment is escaped, so the name can always be parsed back
 func getFullName(namespace string, dev *model.Dev) string {
-	return fmt.Sprintf("%s/%s/%s", namespace, dev.Swap.Deployment.Name, dev.Swap.Deployment.Container)
+	return FullName{
+		Context:    kubeContext,
+		Namespace:  devNamespace(namespace, dev),
+		Deployment: dev.Swap.Deployment.Name,
+		Container:  dev.Swap.Deployment.Container,
+	}.String()
+}
+
+// findName returns the name of the service entry of a dev, falling back to the entry written without a context
+func (s *Storage) findName(namespace string, dev *model.Dev) string {
+	fullName := getFullName(namespace, dev)
+	if _, ok := s.Services[fullName]; ok || kubeContext == "" {
+		return fullName
+	}
+
+	legacy := FullName{Namespace: devNamespace(namespace, dev), Deployment: dev.Swap.Deployment.Name, Container: dev.Swap.Deployment.Container}.String()
+	if _, ok := s.Services[legacy]; ok {
+		return legacy
+	}
+
+	return fullName
+}
+
+// devNamespace returns the namespace of the manifest when no namespace is given
+func devNamespace(namespace string, dev *model.Dev) string {
+	if namespace == "" {
+		return dev.Swap.Deployment.Namespace
+	}
+
+	return namespace
+}
+
+// parseFullName returns the context, namespace, deployment and container of a service name built by getFullName.
+// The context is empty for names without one
+func parseFullName(name string) (string, string, string, string, error) {
+	f, err := ParseFullName(name)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	return f.Context, f.Namespace, f.Deployment, f.Container, nil
+}
+
+// inContext returns true if the entries of the context are visible in the current context, including the ones without a context
+func inContext(context string) bool {
+	return context == "" || context == kubeContext
+}
+
+// inNamespace returns true if the service name belongs to the namespace in the current context
+func inNamespace(name, namespace string) bool {
+	context, ns, _, _, err := parseFullName(name)
+	return err == nil && inContext(context) && ns == namespace
 }
@@ -1,9 +1,18 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/okteto/cnd/pkg/model"
 )
@@ -14,8 +23,9 @@ func TestInsertGetListDelete(t *testing.T) {
 		t.Fatalf("error creating temporal file: %s", err)
 	}
 
-	stPath = tmpfile.Name()
+	SetStoragePath(tmpfile.Name())
 	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
 
 	services := All()
 	if len(services) != 0 {
@@ -28,9 +38,9 @@ func TestInsertGetListDelete(t *testing.T) {
 				Container: "dev1",
 			},
 		},
-		Mount: model.Mount{
+		Mounts: []model.Mount{{
 			Source: "/folder1",
-		},
+		}},
 	}
 	err = Insert("project1", dev1, "localhost1")
 	if err != nil {
@@ -47,9 +57,9 @@ func TestInsertGetListDelete(t *testing.T) {
 				Container: "dev2",
 			},
 		},
-		Mount: model.Mount{
+		Mounts: []model.Mount{{
 			Source: "/folder2",
-		},
+		}},
 	}
 	err = Insert("project2", dev2, "localhost2")
 	if err != nil {
@@ -84,3 +94,1706 @@ func TestInsertGetListDelete(t *testing.T) {
 		t.Fatalf("5 listing should be 1: %d", len(services))
 	}
 }
+
+func TestMetadata(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	dev := &model.Dev{
+		Swap: model.Swap{
+			Deployment: model.Deployment{
+				Name:      "service1",
+				Container: "dev1",
+			},
+		},
+		Mounts: []model.Mount{{
+			Source: "/folder1",
+		}},
+	}
+
+	if err := SetMetadata("project1", dev, "ticket", "CND-1"); err == nil {
+		t.Fatalf("metadata was set on a missing service")
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatalf("error inserting: %s", err)
+	}
+
+	if err := SetMetadata("project1", dev, "", "CND-1"); err == nil {
+		t.Fatalf("metadata was set with an empty key")
+	}
+
+	if err := SetMetadata("project1", dev, "ticket", "CND-1"); err != nil {
+		t.Fatalf("error setting metadata: %s", err)
+	}
+
+	if err := Stop("project1", dev); err != nil {
+		t.Fatalf("error stopping service: %s", err)
+	}
+
+	if err := Insert("project1", dev, "localhost2"); err != nil {
+		t.Fatalf("error inserting: %s", err)
+	}
+
+	value, ok, err := GetMetadata("project1", dev, "ticket")
+	if err != nil {
+		t.Fatalf("error getting metadata: %s", err)
+	}
+
+	if !ok || value != "CND-1" {
+		t.Fatalf("metadata didn't survive stop: %s", value)
+	}
+
+	if _, ok, _ := GetMetadata("project1", dev, "missing"); ok {
+		t.Fatalf("missing metadata key was found")
+	}
+}
+
+func TestRehome(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	folders := map[string]string{
+		"service1": "/old/project",
+		"service2": "/old/project/api",
+		"service3": "/old/projects",
+		"service4": "/other/project",
+	}
+
+	for name, folder := range folders {
+		dev := &model.Dev{
+			Swap:   model.Swap{Deployment: model.Deployment{Name: name}},
+			Mounts: []model.Mount{{Source: folder}},
+		}
+		if err := Insert("project", dev, ""); err != nil {
+			t.Fatalf("error inserting: %s", err)
+		}
+	}
+
+	changed, err := Rehome("/old/project/", "/new/project")
+	if err != nil {
+		t.Fatalf("error rehoming: %s", err)
+	}
+
+	if changed != 2 {
+		t.Errorf("wrong number of changed services: %d", changed)
+	}
+
+	expected := map[string]string{
+		"project/service1/": "/new/project",
+		"project/service2/": "/new/project/api",
+		"project/service3/": "/old/projects",
+		"project/service4/": "/other/project",
+	}
+
+	services := All()
+	for name, folder := range expected {
+		if services[name].Folder != folder {
+			t.Errorf("wrong folder for %s: %s", name, services[name].Folder)
+		}
+	}
+}
+
+func TestRecoverCorrupted(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+	defer os.Remove(tmpfile.Name() + ".corrupt")
+
+	if err := ioutil.WriteFile(stPath, []byte("services: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := load(); err == nil {
+		t.Fatal("corrupted storage was loaded")
+	}
+
+	RecoverCorrupted = true
+	defer func() { RecoverCorrupted = false }()
+
+	s, err := load()
+	if err != nil {
+		t.Fatalf("corrupted storage was not recovered: %s", err)
+	}
+
+	if len(s.Services) != 0 {
+		t.Errorf("recovered storage is not empty: %+v", s.Services)
+	}
+
+	if b, err := ioutil.ReadFile(stPath + ".corrupt"); err != nil || string(b) != "services: [" {
+		t.Errorf("corrupted storage was not backed up: %s", err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(stPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), "checksum: ") {
+		t.Fatalf("checksum was not saved:\n%s", b)
+	}
+
+	if _, err := load(); err != nil {
+		t.Fatalf("valid storage was not loaded: %s", err)
+	}
+
+	tampered := strings.Replace(string(b), "/folder1", "/folder2", 1)
+	if err := ioutil.WriteFile(stPath, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := load(); err != ErrStorageCorrupt {
+		t.Errorf("tampered storage was loaded: %v", err)
+	}
+
+	legacy := "version: \"1.0\"\nservices:\n  project1/service1/:\n    folder: /folder1\n"
+	if err := ioutil.WriteFile(stPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatalf("storage without checksum was not loaded: %s", err)
+	}
+
+	if s.Services["project1/service1/"].Folder != "/folder1" {
+		t.Errorf("wrong services: %+v", s.Services)
+	}
+}
+
+func TestReset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if err := Reset(); err != nil {
+		t.Fatalf("missing storage was not reset: %s", err)
+	}
+
+	if _, err := os.Stat(stPath); err != nil {
+		t.Fatalf("storage file was not created: %s", err)
+	}
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(stPath, []byte("services: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Reset(); err != nil {
+		t.Fatalf("corrupted storage was not reset: %s", err)
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Version != version || len(s.Services) != 0 {
+		t.Errorf("storage was not reset: %+v", s)
+	}
+}
+
+func TestConfigDriftedFrom(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	dev := &model.Dev{
+		Swap:    model.Swap{Deployment: model.Deployment{Name: "service1", Image: "okteto/cnd:1"}},
+		Mounts:  []model.Mount{{Source: "/folder1", Target: "/app"}},
+		Scripts: map[string]string{"test": "make test"},
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatalf("error inserting: %s", err)
+	}
+
+	svc, err := Get("project1", dev)
+	if err != nil {
+		t.Fatalf("error getting service: %s", err)
+	}
+
+	if drifted, _ := svc.ConfigDriftedFrom(dev); drifted {
+		t.Errorf("unchanged dev drifted")
+	}
+
+	dev.Swap.Deployment.Image = "okteto/cnd:2"
+	dev.Scripts["lint"] = "make lint"
+	drifted, areas := svc.ConfigDriftedFrom(dev)
+	if !drifted || !reflect.DeepEqual(areas, []string{"scripts", "swap"}) {
+		t.Errorf("wrong drift: %t %+v", drifted, areas)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	// flock locks are per open file, so locking another open file behaves like another process.
+	// acquireLock isn't used, since it also takes the mutex of this process
+	l, err := os.OpenFile(tmpfile.Name()+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tryLock(l); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := lockTimeout
+	lockTimeout = 100 * time.Millisecond
+	defer func() { lockTimeout = previous }()
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	err = Insert("project1", dev, "localhost1")
+	if runtime.GOOS != "windows" && (err == nil || !strings.Contains(err.Error(), "could not acquire storage lock")) {
+		t.Errorf("insert didn't wait for the lock: %v", err)
+	}
+
+	unlock(l)
+	l.Close()
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Errorf("insert failed after the lock was released: %s", err)
+	}
+}
+
+func TestSavePartialWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := ioutil.ReadFile(stPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := writeStorage
+	writeStorage = func(f *os.File, b []byte) error {
+		f.Write(b[:len(b)/2])
+		return fmt.Errorf("killed")
+	}
+	defer func() { writeStorage = previous }()
+
+	if err := Stop("project1", dev); err == nil {
+		t.Fatal("partial write didn't fail")
+	}
+
+	after, err := ioutil.ReadFile(stPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("state was modified by a partial write:\n%s", string(after))
+	}
+
+	info, err := os.Stat(stPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("wrong permissions: %s", info.Mode())
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, ".state.tmp*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 0 {
+		t.Errorf("temporal files were left behind: %v", files)
+	}
+}
+
+func TestStartedAt(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	old := []byte(`version: "1.0"
+services:
+  project1/service1/dev1:
+    folder: /folder1
+    syncthing: localhost1
+`)
+	if err := ioutil.WriteFile(stPath, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	services := All()
+	if svc, ok := services["project1/service1/dev1"]; !ok || !svc.StartedAt.IsZero() {
+		t.Fatalf("old state file wasn't loaded: %+v", services)
+	}
+
+	before := time.Now()
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service2"}}, Mounts: []model.Mount{{Source: "/folder2"}}}
+	if err := Insert("project1", dev, "localhost2"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.StartedAt.Before(before.Add(-time.Second)) || svc.StartedAt.After(time.Now()) {
+		t.Errorf("wrong start time: %s", svc.StartedAt)
+	}
+}
+
+func TestAllInNamespace(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	for _, namespace := range []string{"dev", "dev-team", "prod"} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service", Container: "api"}}, Mounts: []model.Mount{{Source: "/" + namespace}}}
+		if err := Insert(namespace, dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services := AllInNamespace("dev")
+	if len(services) != 1 {
+		t.Fatalf("wrong services: %+v", services)
+	}
+
+	if svc, ok := services["dev/service/api"]; !ok || svc.Folder != "/dev" {
+		t.Errorf("wrong services: %+v", services)
+	}
+
+	if services := AllInNamespace("staging"); len(services) != 0 {
+		t.Errorf("wrong services: %+v", services)
+	}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	for _, namespace := range []string{"dev", "dev-staging", "prod"} {
+		for _, name := range []string{"service", "worker"} {
+			dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name, Container: "api"}}, Mounts: []model.Mount{{Source: "/" + namespace + "/" + name}}}
+			if err := Insert(namespace, dev, "localhost"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deleted, err := DeleteNamespace("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("wrong number of deleted services: %d", deleted)
+	}
+
+	if services := AllInNamespace("dev"); len(services) != 0 {
+		t.Errorf("services were not deleted: %+v", services)
+	}
+
+	if services := AllInNamespace("dev-staging"); len(services) != 2 {
+		t.Errorf("services of other namespaces were deleted: %+v", services)
+	}
+
+	deleted, err = DeleteNamespace("staging")
+	if err != nil || deleted != 0 {
+		t.Errorf("wrong result for a namespace without services: %d, %v", deleted, err)
+	}
+}
+
+func TestGetByDeployment(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "cnd-storage")
+	if err != nil {
+		t.Fatalf("error creating temporal file: %s", err)
+	}
+
+	SetStoragePath(tmpfile.Name())
+	defer os.Remove(tmpfile.Name())
+	defer os.Remove(tmpfile.Name() + ".lock")
+
+	for _, d := range []struct{ name, container string }{{"service", "web"}, {"service", "api"}, {"service-2", "api"}} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: d.name, Container: d.container}}, Mounts: []model.Mount{{Source: "/" + d.name + "/" + d.container}}}
+		if err := Insert("project1", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services, err := GetByDeployment("project1", "service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 2 || services[0].Folder != "/service/api" || services[1].Folder != "/service/web" {
+		t.Errorf("wrong services: %+v", services)
+	}
+
+	if _, err := GetByDeployment("project1", "missing"); err == nil || !strings.Contains(err.Error(), "project1/missing") {
+		t.Errorf("wrong error for a missing deployment: %v", err)
+	}
+}
+
+func TestStopAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if err := StopAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stPath); !os.IsNotExist(err) {
+		t.Errorf("empty storage was saved: %v", err)
+	}
+
+	for _, name := range []string{"service1", "service2"} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name}}, Mounts: []model.Mount{{Source: "/" + name}}}
+		if err := Insert("project1", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := StopAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	services := All()
+	if len(services) != 2 {
+		t.Fatalf("services were deleted: %+v", services)
+	}
+
+	for name, svc := range services {
+		if svc.Syncthing != "" || svc.Folder == "" {
+			t.Errorf("%s wasn't stopped: %+v", name, svc)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+
+	running, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer running.Close()
+
+	crashed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed.Close()
+
+	hosts := map[string]string{"running": running.Addr().String(), "crashed": crashed.Addr().String(), "stopped": ""}
+	for name, host := range hosts {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name}}, Mounts: []model.Mount{{Source: "/" + name}}}
+		if err := Insert("project1", dev, host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(pruned, []string{"project1/crashed/"}) {
+		t.Errorf("wrong pruned services: %+v", pruned)
+	}
+
+	services := All()
+	if services["project1/running/"].Syncthing != hosts["running"] || services["project1/crashed/"].Syncthing != "" {
+		t.Errorf("wrong services after pruning: %+v", services)
+	}
+
+	if !services["project1/running/"].IsReachable() {
+		t.Errorf("running service is not reachable")
+	}
+}
+
+func TestStoragePath(t *testing.T) {
+	previous := StoragePath()
+	defer SetStoragePath(previous)
+
+	SetStoragePath("/tmp/cnd/.state")
+	if StoragePath() != "/tmp/cnd/.state" {
+		t.Errorf("%s != /tmp/cnd/.state", StoragePath())
+	}
+}
+
+func TestInsertWithOriginal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := InsertWithOriginal("project1", dev, "localhost1", []string{"python"}, []string{"app.py"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop("project1", dev); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Insert("project1", dev, "localhost2"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(svc.OriginalCommand, []string{"python"}) || !reflect.DeepEqual(svc.OriginalArgs, []string{"app.py"}) {
+		t.Errorf("original command wasn't preserved: %+v", svc)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	for _, d := range []struct{ namespace, name, container string }{{"project2", "api", "app"}, {"project1", "web", ""}, {"project1", "api", "worker"}} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: d.name, Container: d.container}}, Mounts: []model.Mount{{Source: "/" + d.namespace + "/" + d.name}}}
+		if err := Insert(d.namespace, dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := List()
+	expected := []string{"project1/api/worker", "project1/web/", "project2/api/app"}
+	if len(entries) != len(expected) {
+		t.Fatalf("wrong entries: %+v", entries)
+	}
+
+	for i, name := range expected {
+		if entries[i].Name != name {
+			t.Errorf("%s != %s", entries[i].Name, name)
+		}
+	}
+
+	e := entries[0]
+	if e.Namespace != "project1" || e.Deployment != "api" || e.Container != "worker" || e.Folder != "/project1/api" || e.Syncthing != "localhost" {
+		t.Errorf("wrong entry: %+v", e)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Services = map[string]Service{
+		"project/api/app":  {Folder: "/api"},
+		"project/web/app":  {Folder: "/web"},
+		"kind/project/db/": {Folder: "/db"},
+		"invalid":          {Folder: "/invalid"},
+	}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	remapped, err := Compact(func(old string) (string, bool) {
+		if strings.Count(old, "/") == 3 {
+			return old, true
+		}
+
+		if strings.Count(old, "/") == 2 {
+			return "kind/" + old, true
+		}
+
+		return "", false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if remapped != 2 {
+		t.Errorf("%d != 2", remapped)
+	}
+
+	expected := map[string]string{"kind/project/api/app": "/api", "kind/project/web/app": "/web", "kind/project/db/": "/db"}
+	services := All()
+	if len(services) != len(expected) {
+		t.Errorf("wrong services: %+v", services)
+	}
+
+	for name, folder := range expected {
+		if services[name].Folder != folder {
+			t.Errorf("wrong service %s: %+v", name, services[name])
+		}
+	}
+
+	_, err = Compact(func(old string) (string, bool) {
+		return "kind/project/api/app", true
+	})
+	if err == nil {
+		t.Errorf("conflicting keys were compacted")
+	}
+
+	if len(All()) != len(expected) {
+		t.Errorf("services were changed by a failed compaction: %+v", All())
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	const services = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, services)
+	for i := 0; i < services; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: fmt.Sprintf("service%d", i)}}, Mounts: []model.Mount{{Source: fmt.Sprintf("/folder%d", i)}}}
+			if err := Insert("project", dev, "localhost"); err != nil {
+				errs <- err
+				return
+			}
+
+			if _, err := Get("project", dev); err != nil {
+				errs <- err
+				return
+			}
+
+			All()
+			if err := Stop("project", dev); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if len(All()) != services {
+		t.Errorf("updates were lost: %d != %d", len(All()), services)
+	}
+}
+
+func TestDeployments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	for _, d := range []struct{ namespace, name, container string }{{"project2", "api", "app"}, {"project1", "web", ""}, {"project2", "api", "worker"}, {"project1", "api", "app"}} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: d.name, Container: d.container}}, Mounts: []model.Mount{{Source: "/" + d.namespace + "/" + d.name + "/" + d.container}}}
+		if err := Insert(d.namespace, dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Services["not-a-service-name"] = Service{Folder: "/invalid"}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	refs := Deployments()
+	expected := []DeploymentRef{{Namespace: "project1", Deployment: "api"}, {Namespace: "project1", Deployment: "web"}, {Namespace: "project2", Deployment: "api"}}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("%+v != %+v", refs, expected)
+	}
+}
+
+func TestFullNameRoundTrip(t *testing.T) {
+	var tests = []struct {
+		context    string
+		namespace  string
+		deployment string
+		container  string
+	}{
+		{namespace: "project1", deployment: "api", container: "app"},
+		{namespace: "project1", deployment: "api", container: ""},
+		{namespace: "team/dev", deployment: "api.v2", container: "app%20/worker"},
+		{context: "kind/local", namespace: "project1", deployment: "api", container: "app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.context+tt.namespace+tt.container, func(t *testing.T) {
+			SetContext(tt.context)
+			dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: tt.deployment, Container: tt.container}}}
+			context, namespace, deployment, container, err := parseFullName(getFullName(tt.namespace, dev))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if context != tt.context || namespace != tt.namespace || deployment != tt.deployment || container != tt.container {
+				t.Errorf("%s/%s/%s/%s != %s/%s/%s/%s", context, namespace, deployment, container, tt.context, tt.namespace, tt.deployment, tt.container)
+			}
+		})
+	}
+
+	SetContext("")
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Container: "app"}}}
+	if getFullName("project1", dev) != "project1/api/app" {
+		t.Errorf("names without special characters are escaped: %s", getFullName("project1", dev))
+	}
+}
+
+func TestFullNameExpandedDeployment(t *testing.T) {
+	os.Setenv("CND_TEST_SUFFIX", "-staging")
+	defer os.Unsetenv("CND_TEST_SUFFIX")
+
+	dev, err := model.LoadDev([]byte("swap:\n  deployment:\n    name: proj-api${CND_TEST_SUFFIX}\n    container: api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := getFullName("project1", dev); name != "project1/proj-api-staging/api" {
+		t.Errorf("the deployment name wasn't expanded: %s", name)
+	}
+}
+
+func TestContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetContext("")
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Container: "app"}}, Mounts: []model.Mount{{Source: "/legacy"}}}
+	if err := Insert("project1", dev, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	SetContext("kind")
+	if svc, err := Get("project1", dev); err != nil || svc.Folder != "/legacy" {
+		t.Fatalf("entry without a context was not found: %+v, %v", svc, err)
+	}
+
+	dev.Mounts[0].Source = "/kind"
+	if err := Insert("project1", dev, "localhost:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	SetContext("staging")
+	dev.Mounts[0].Source = "/staging"
+	if err := Insert("project1", dev, "localhost:2"); err != nil {
+		t.Fatal(err)
+	}
+
+	services := All()
+	if len(services) != 2 {
+		t.Fatalf("wrong services: %+v", services)
+	}
+
+	if svc := services["kind/project1/api/app"]; svc.Folder != "/kind" || svc.Context != "kind" {
+		t.Errorf("wrong service in the kind context: %+v", svc)
+	}
+
+	if svc := services["staging/project1/api/app"]; svc.Folder != "/staging" || svc.Context != "staging" {
+		t.Errorf("wrong service in the staging context: %+v", svc)
+	}
+
+	if services := AllInNamespace("project1"); len(services) != 1 {
+		t.Errorf("services of other contexts were returned: %+v", services)
+	}
+
+	entries := List()
+	if len(entries) != 2 || entries[0].Context != "kind" || entries[0].Namespace != "project1" || entries[0].Container != "app" {
+		t.Errorf("wrong entries: %+v", entries)
+	}
+}
+
+func TestManifestNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Namespace: "team"}}, Mounts: []model.Mount{{Source: "/api"}}}
+	if err := Insert("", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := All()["team/api/"]; !ok {
+		t.Errorf("the manifest namespace was not used: %+v", All())
+	}
+
+	if _, err := Get("team", dev); err != nil {
+		t.Errorf("service was not found in the manifest namespace: %s", err)
+	}
+
+	if _, err := Get("other", dev); err == nil {
+		t.Error("the given namespace didn't take precedence over the manifest namespace")
+	}
+}
+
+func TestTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}, Mounts: []model.Mount{{Source: "/api", Target: "/app"}}}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.Target != "/app" {
+		t.Errorf("%s != /app", svc.Target)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte("services:\n  project/web/:\n    folder: /web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if svc := All()["project/web/"]; svc.Folder != "/web" || svc.Target != "" {
+		t.Errorf("wrong service from an old state file: %+v", svc)
+	}
+}
+
+func TestServiceString(t *testing.T) {
+	s := Service{Folder: "/home/user/api", Syncthing: "localhost:60000", Status: StatusSynced}
+	expected := "folder=/home/user/api host=localhost:60000"
+	if s.String() != expected {
+		t.Errorf("%s != %s", s.String(), expected)
+	}
+
+	expected = "folder= host="
+	if s := (Service{}).String(); s != expected {
+		t.Errorf("%s != %s", s, expected)
+	}
+}
+
+func TestResolvedHost(t *testing.T) {
+	os.Setenv("CND_TEST_SYNCTHING_HOST", "10.0.0.1:8384")
+	defer os.Unsetenv("CND_TEST_SYNCTHING_HOST")
+	os.Unsetenv("CND_TEST_UNSET_HOST")
+
+	var tests = []struct {
+		name      string
+		syncthing string
+		expected  string
+	}{
+		{name: "literal", syncthing: "localhost:8384", expected: "localhost:8384"},
+		{name: "env", syncthing: "${CND_TEST_SYNCTHING_HOST}", expected: "10.0.0.1:8384"},
+		{name: "env-in-url", syncthing: "http://${CND_TEST_SYNCTHING_HOST}/", expected: "http://10.0.0.1:8384/"},
+		{name: "unset", syncthing: "${CND_TEST_UNSET_HOST}", expected: ""},
+		{name: "empty", syncthing: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Service{Syncthing: tt.syncthing}
+			if host := s.ResolvedHost(); host != tt.expected {
+				t.Errorf("%s != %s", host, tt.expected)
+			}
+
+			if s.Syncthing != tt.syncthing {
+				t.Errorf("the stored host was changed: %s", s.Syncthing)
+			}
+		})
+	}
+}
+
+func TestUpdateHost(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := UpdateHost("project1", dev, "localhost2"); err == nil {
+		t.Fatal("missing service was updated")
+	}
+
+	if len(All()) != 0 {
+		t.Fatalf("missing service was created: %+v", All())
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateHost("project1", dev, "localhost2"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.Syncthing != "localhost2" || after.Folder != before.Folder || !after.StartedAt.Equal(before.StartedAt) {
+		t.Errorf("wrong service after updating the host: %+v", after)
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if ok, err := Exists("project1", dev); ok || err != nil {
+		t.Errorf("missing service exists: %t %v", ok, err)
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := Exists("project1", dev); !ok || err != nil {
+		t.Errorf("service doesn't exist: %t %v", ok, err)
+	}
+
+	if ok, err := Exists("project2", dev); ok || err != nil {
+		t.Errorf("service exists in another namespace: %t %v", ok, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte("services: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Exists("project1", dev); err == nil {
+		t.Errorf("corrupted storage wasn't reported")
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/old/path"}}}
+	if err := Relocate("project1", dev, "/new/path"); err == nil {
+		t.Fatal("missing service was relocated")
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Relocate("project1", dev, "/new/path"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.Folder != "/new/path" || svc.Syncthing != "localhost1" {
+		t.Errorf("wrong service after relocating it: %+v", svc)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "service1"}}, Mounts: []model.Mount{{Source: "/folder1"}}}
+	if err := SetStatus("project1", dev, StatusSynced); err == nil {
+		t.Fatal("missing service was updated")
+	}
+
+	if err := Insert("project1", dev, "localhost1"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.Status != "" {
+		t.Errorf("new service has a status: %s", svc.Status)
+	}
+
+	if err := SetStatus("project1", dev, "paused"); err == nil {
+		t.Error("unknown status was accepted")
+	}
+
+	if err := SetStatus("project1", dev, StatusSyncing); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err = Get("project1", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if svc.Status != StatusSyncing || svc.Syncthing != "localhost1" {
+		t.Errorf("wrong service after updating the status: %+v", svc)
+	}
+}
+
+func TestGarbageCollect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	existing := filepath.Join(dir, "existing")
+	if err := os.Mkdir(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []struct{ name, folder, host string }{
+		{"existing", existing, ""},
+		{"deleted", filepath.Join(dir, "deleted"), ""},
+		{"running", filepath.Join(dir, "running"), "localhost"},
+	} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: d.name}}, Mounts: []model.Mount{{Source: d.folder}}}
+		if err := Insert("project1", dev, d.host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := GarbageCollect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(deleted, []string{"project1/deleted/"}) {
+		t.Errorf("wrong deleted services: %+v", deleted)
+	}
+
+	services := All()
+	if _, ok := services["project1/existing/"]; !ok || len(services) != 2 {
+		t.Errorf("wrong services after the garbage collection: %+v", services)
+	}
+
+	if deleted, err := GarbageCollect(); err != nil || len(deleted) != 0 {
+		t.Errorf("services were deleted twice: %+v, %v", deleted, err)
+	}
+}
+
+func TestFolderConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { FailOnFolderConflict = false }()
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	api := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Container: "api"}}, Mounts: []model.Mount{{Source: "/src", Target: "/app"}}}
+	if err := Insert("project", api, "localhost:60000"); err != nil {
+		t.Fatal(err)
+	}
+
+	worker := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Container: "worker"}}, Mounts: []model.Mount{{Source: "/src", Target: "/app"}}}
+	FailOnFolderConflict = true
+	if err := Insert("project", worker, "localhost:60001"); !errors.Is(err, ErrFolderConflict) {
+		t.Errorf("the folder conflict wasn't detected: %v", err)
+	}
+
+	if err := Insert("project", worker, "localhost:60000"); err != nil {
+		t.Errorf("the same host was a conflict: %s", err)
+	}
+
+	web := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "web"}}, Mounts: []model.Mount{{Source: "/src", Target: "/app"}}}
+	if err := Insert("project", web, ""); err != nil {
+		t.Errorf("a service without file synchronization was a conflict: %s", err)
+	}
+
+	FailOnFolderConflict = false
+	if err := Insert("project", web, "localhost:60002"); err != nil {
+		t.Errorf("the folder conflict wasn't a warning: %s", err)
+	}
+}
+
+func TestPreviewInsert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}, Mounts: []model.Mount{{Source: "/api", Target: "/app"}}}
+
+	if outcome, err := PreviewInsert("project", dev, "localhost:60000"); err != nil || outcome != InsertCreated {
+		t.Errorf("wrong outcome: %s, %v", outcome, err)
+	}
+
+	if len(All()) != 0 {
+		t.Errorf("the preview was saved: %+v", All())
+	}
+
+	if err := Insert("project", dev, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name     string
+		host     string
+		expected InsertOutcome
+	}{
+		{name: "unchanged", host: "", expected: InsertUnchanged},
+		{name: "updated", host: "localhost:60000", expected: InsertUpdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := PreviewInsert("project", dev, tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if outcome != tt.expected {
+				t.Errorf("%s != %s", outcome, tt.expected)
+			}
+		})
+	}
+
+	if err := Insert("project", dev, "localhost:60000"); err != nil {
+		t.Fatal(err)
+	}
+
+	if outcome, err := PreviewInsert("project", dev, "localhost:60001"); err != nil || outcome != InsertAlreadyRunning {
+		t.Errorf("wrong outcome: %s, %v", outcome, err)
+	}
+
+	if svc := All()["project/api/"]; svc.Syncthing != "localhost:60000" {
+		t.Errorf("the preview changed the service: %+v", svc)
+	}
+}
+
+func TestServicesUnder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	for _, d := range []struct{ name, folder string }{{"api", "/projects/shop"}, {"web", "/projects/shop/web/"}, {"docs", "/projects/shopping"}} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: d.name}}, Mounts: []model.Mount{{Source: d.folder}}}
+		if err := Insert("project", dev, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		name     string
+		dir      string
+		expected []string
+	}{
+		{name: "nested", dir: "/projects/shop", expected: []string{"project/api/", "project/web/"}},
+		{name: "trailing-slash", dir: "/projects/shop/", expected: []string{"project/api/", "project/web/"}},
+		{name: "exact", dir: "/projects/shop/web", expected: []string{"project/web/"}},
+		{name: "root", dir: "/", expected: []string{"project/api/", "project/docs/", "project/web/"}},
+		{name: "none", dir: "/other", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := ServicesUnder(tt.dir)
+			names := []string{}
+			for _, e := range entries {
+				names = append(names, e.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("%v != %v", names, tt.expected)
+			}
+		})
+	}
+
+	if entries := ServicesUnder("/other"); entries == nil {
+		t.Errorf("nil entries were returned")
+	}
+}
+
+func TestEmptyFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}}
+	if err := Insert("project", dev, "localhost"); err == nil || !strings.Contains(err.Error(), "folder of the service entry cannot be empty") {
+		t.Errorf("empty folder was accepted: %v", err)
+	}
+
+	state := "services:\n  project/api/:\n    folder: /api\n  project/empty/:\n    syncthing: localhost\n  project/relative/:\n    folder: src\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, ".state"), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	services := All()
+	if len(services) != 1 || services["project/api/"].Folder != "/api" {
+		t.Errorf("invalid entries weren't skipped: %+v", services)
+	}
+
+	if _, err := Get("project", &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "empty"}}}); err == nil {
+		t.Errorf("the empty folder entry was found")
+	}
+
+	if err := Insert("project", &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "web"}}, Mounts: []model.Mount{{Source: "/web"}}}, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Delete("project", &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"project/empty/", "project/relative/", "project/web/"} {
+		if !strings.Contains(string(b), name) {
+			t.Errorf("the entry %s wasn't preserved by the updates: %s", name, b)
+		}
+	}
+
+	if err := Delete("project", &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "relative"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(dir, ".state")); err != nil || strings.Contains(string(b), "project/relative/") {
+		t.Errorf("the invalid entry wasn't deleted: %s %v", b, err)
+	}
+}
+
+func TestReup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	devPath := filepath.Join(dir, "cnd.yml")
+	if err := ioutil.WriteFile(devPath, []byte("swap:\n  deployment:\n    name: api\n    container: app\nmounts:\n  - source: .\n    target: /app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := model.ReadDev(devPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if svc, _ := Get("project", dev); svc == nil || svc.Manifest != devPath {
+		t.Fatalf("the manifest wasn't stored: %+v", svc)
+	}
+
+	d, err := Reup("project", "api", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !d.Equal(dev) {
+		t.Errorf("%s != %s", d, dev)
+	}
+
+	if _, err := Reup("project", "web", ""); err == nil {
+		t.Errorf("a missing service was found")
+	}
+
+	if err := os.Remove(devPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Reup("project", "api", "app"); err == nil || !strings.Contains(err.Error(), "it was moved or deleted") {
+		t.Errorf("wrong error for a missing manifest: %v", err)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	var devs []*model.Dev
+	for _, name := range []string{"api", "web", "db"} {
+		devs = append(devs, &model.Dev{
+			Swap:   model.Swap{Deployment: model.Deployment{Name: name, Container: "app"}},
+			Mounts: []model.Mount{{Source: "/home/user/" + name, Target: "/app"}},
+		})
+	}
+
+	for _, dev := range devs[:2] {
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services, errs := GetMany("project", devs)
+	if len(services) != 2 || services["project/api/app"].Folder != "/home/user/api" || services["project/web/app"].Folder != "/home/user/web" {
+		t.Errorf("the services weren't found: %+v", services)
+	}
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "project/db/app") {
+		t.Errorf("the missing service wasn't reported: %v", errs)
+	}
+}
+
+// flakyBackend fails the first loads with err
+type flakyBackend struct {
+	Backend
+	failures int
+	loads    int
+	err      error
+}
+
+func (b *flakyBackend) Load() (*Storage, error) {
+	b.loads++
+	if b.loads <= b.failures {
+		return nil, b.err
+	}
+
+	return b.Backend.Load()
+}
+
+func TestLoadForUpdateRetries(t *testing.T) {
+	attempts, backoff := loadAttempts, loadBackoff
+	loadBackoff = time.Millisecond
+	defer func() { loadAttempts, loadBackoff = attempts, backoff }()
+	defer SetBackend(nil)
+
+	dev := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "api", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/api", Target: "/app"}},
+	}
+
+	var tests = []struct {
+		name     string
+		failures int
+		err      error
+		loads    int
+		fails    bool
+	}{
+		{name: "transient", failures: 2, err: fmt.Errorf("%w: input/output error", errStorageRead), loads: 3, fails: false},
+		{name: "persistent", failures: 3, err: fmt.Errorf("%w: input/output error", errStorageRead), loads: 3, fails: true},
+		{name: "unmarshalling", failures: 1, err: fmt.Errorf("error unmarshalling the storage file"), loads: 1, fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &flakyBackend{Backend: NewMemoryBackend(), failures: tt.failures, err: tt.err}
+			SetBackend(b)
+
+			err := Insert("project", dev, "localhost")
+			if tt.fails && err == nil {
+				t.Errorf("the load error was ignored")
+			}
+
+			if !tt.fails && err != nil {
+				t.Errorf("the transient error wasn't retried: %s", err)
+			}
+
+			if b.loads != tt.loads {
+				t.Errorf("%d loads != %d", b.loads, tt.loads)
+			}
+		})
+	}
+}
+
+func TestAuditFunc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	var ops []string
+	AuditFunc = func(op string, key string) {
+		ops = append(ops, op+" "+key)
+	}
+	defer func() { AuditFunc = nil }()
+
+	dev := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "api", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/api", Target: "/app"}},
+	}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop("project", dev); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Delete("project", dev); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Delete("project", dev); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"insert project/api/app", "stop project/api/app", "delete project/api/app"}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("%v != %v", ops, expected)
+	}
+
+	web := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "web", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/web", Target: "/app"}},
+	}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Insert("project", web, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop("project", web); err != nil {
+		t.Fatal(err)
+	}
+
+	ops = nil
+	if err := StopAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := StopAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"stop project/api/app"}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("%v != %v", ops, expected)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	for _, name := range []string{"api", "web", "db"} {
+		dev := &model.Dev{
+			Swap:   model.Swap{Deployment: model.Deployment{Name: name, Container: "app"}},
+			Mounts: []model.Mount{{Source: "/home/user/" + name, Target: "/app"}},
+		}
+		if err := Insert("project", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetContext("other")
+	dev := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "cron", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/cron", Target: "/app"}},
+	}
+	if err := Insert("project", dev, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+	SetContext("")
+
+	if _, err := Reconcile(func(namespace, deployment, container string) (bool, error) {
+		return false, fmt.Errorf("connection refused")
+	}); err == nil {
+		t.Errorf("the failed check was ignored")
+	}
+
+	var checked []string
+	deleted, err := Reconcile(func(namespace, deployment, container string) (bool, error) {
+		checked = append(checked, namespace+"/"+deployment+"/"+container)
+		return deployment == "web", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"project/api/app", "project/db/app"}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Errorf("%v != %v", deleted, expected)
+	}
+
+	if len(checked) != 3 {
+		t.Errorf("the services of other contexts were checked: %v", checked)
+	}
+
+	if services := All(); len(services) != 2 {
+		t.Errorf("wrong services after reconciling: %+v", services)
+	}
+
+	reinserted := &model.Dev{
+		Swap:   model.Swap{Deployment: model.Deployment{Name: "web", Container: "app"}},
+		Mounts: []model.Mount{{Source: "/home/user/web-v2", Target: "/app"}},
+	}
+	deleted, err = Reconcile(func(namespace, deployment, container string) (bool, error) {
+		if err := Delete(namespace, reinserted); err != nil {
+			return false, err
+		}
+
+		if err := Insert(namespace, reinserted, "localhost"); err != nil {
+			return false, err
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 0 {
+		t.Errorf("the entry inserted again during the check was deleted: %v", deleted)
+	}
+
+	if svc, err := Get("project", reinserted); err != nil || svc.Folder != "/home/user/web-v2" {
+		t.Errorf("the entry inserted again wasn't kept: %+v %v", svc, err)
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServicesText returns a tab-separated line per active cnd service, sorted by name, e.g. to grep the state in shell scripts.
+// Each line has the name, folder, syncthing host and status of the service, which may be empty
+func ServicesText() string {
+	services := All()
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		svc := services[name]
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\n", name, svc.Folder, svc.ResolvedHost(), svc.Status)
+	}
+
+	return b.String()
+}
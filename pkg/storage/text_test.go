@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestServicesText(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	if text := ServicesText(); text != "" {
+		t.Errorf("wrong text for an empty storage: %q", text)
+	}
+
+	for _, name := range []string{"web", "api"} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name, Container: "app"}}, Mounts: []model.Mount{{Source: "/" + name}}}
+		if err := Insert("project", dev, "localhost:"+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: "api", Container: "app"}}}
+	if err := SetStatus("project", dev, StatusSynced); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "project/api/app\t/api\tlocalhost:api\tsynced\nproject/web/app\t/web\tlocalhost:web\t\n"
+	if text := ServicesText(); text != expected {
+		t.Errorf("%q != %q", text, expected)
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Verify reads the storage file as written, and returns a warning for each service entry that load silently drops:
+// the keys defined more than once, and the different keys that refer to the same container once unescaped.
+// Only the last of those entries is kept when the storage is loaded, so the file should be fixed by hand
+func Verify() ([]string, error) {
+	b, err := ioutil.ReadFile(stPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading the storage file: %s", err.Error())
+	}
+
+	// a MapSlice keeps the duplicated keys that a map would silently dedupe
+	var raw struct {
+		Services yaml.MapSlice `yaml:"services,omitempty"`
+	}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("error unmarshalling the storage file: %s", err.Error())
+	}
+
+	var warnings []string
+	counts := map[string]int{}
+	effective := map[string]string{}
+	for _, item := range raw.Services {
+		name := fmt.Sprint(item.Key)
+		counts[name]++
+		if counts[name] == 2 {
+			warnings = append(warnings, fmt.Sprintf("the service '%s' is defined more than once, only the last entry is used", name))
+		}
+
+		context, namespace, deployment, container, err := parseFullName(name)
+		if err != nil {
+			continue
+		}
+
+		key := strings.Join([]string{context, namespace, deployment, container}, "\x00")
+		if other, ok := effective[key]; ok && other != name {
+			warnings = append(warnings, fmt.Sprintf("the services '%s' and '%s' refer to the same container, only one of them is used", other, name))
+			continue
+		}
+
+		effective[key] = name
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	warnings, err := Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("a missing storage file has warnings: %v", warnings)
+	}
+
+	state := `version: "1.0"
+services:
+  project/api/app:
+    folder: /home/user/api
+  project/web/app:
+    folder: /home/user/web
+  project/api/app:
+    folder: /home/user/api-copy
+  project/my-db/app:
+    folder: /home/user/db
+  project/my%2Ddb/app:
+    folder: /home/user/db-copy
+`
+	if err := ioutil.WriteFile(StoragePath(), []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings, err = Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"the service 'project/api/app' is defined more than once, only the last entry is used",
+		"the services 'project/my-db/app' and 'project/my%2Ddb/app' refer to the same container, only one of them is used",
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("%v != %v", warnings, expected)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// watchInterval is how often Watch checks the storage file. A change is emitted once the file is unchanged for an interval,
+// so the rapid successive writes of a command are debounced
+var watchInterval = 250 * time.Millisecond
+
+// Watch emits the active cnd services right away, and again every time the storage file changes, until the context is done.
+// The storage file is checked by its modification time and size, so consumers don't need to re-read it
+func Watch(ctx context.Context) (<-chan []ServiceEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := make(chan []ServiceEntry, 1)
+	last := storageFileVersion()
+	c <- List()
+
+	go func() {
+		defer close(c)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		pending := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if v := storageFileVersion(); v != last {
+				last = v
+				pending = true
+				continue
+			}
+
+			if !pending {
+				continue
+			}
+
+			pending = false
+			select {
+			case c <- List():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+// storageFileVersion identifies the current content of the storage file, which is replaced on every save
+func storageFileVersion() string {
+	info, err := os.Stat(stPath)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/okteto/cnd/pkg/model"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnd-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	previous := watchInterval
+	watchInterval = 10 * time.Millisecond
+	defer func() { watchInterval = previous }()
+
+	SetStoragePath(filepath.Join(dir, ".state"))
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries := <-c; len(entries) != 0 {
+		t.Errorf("wrong initial snapshot: %+v", entries)
+	}
+
+	for _, name := range []string{"api", "worker"} {
+		dev := &model.Dev{Swap: model.Swap{Deployment: model.Deployment{Name: name}}, Mounts: []model.Mount{{Source: "/" + name}}}
+		if err := Insert("project1", dev, "localhost"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case entries := <-c:
+			if len(entries) != 2 {
+				continue
+			}
+
+			cancel()
+			for range c {
+			}
+			return
+		case <-timeout:
+			t.Fatal("the changes were not emitted")
+		}
+	}
+}